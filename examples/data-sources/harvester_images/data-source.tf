@@ -0,0 +1,13 @@
+data "harvester_images" "ubuntu" {
+  namespace          = "harvester-public"
+  display_name_regex = "^ubuntu-22\\.04-"
+  os_type            = "ubuntu"
+  state              = "Active"
+  most_recent        = true
+}
+
+data "harvester_images" "golden" {
+  tags = {
+    pipeline = "golden"
+  }
+}
@@ -0,0 +1,4 @@
+data "harvester_volume_snapshot" "mount-disk-snapshot" {
+  name      = "mount-disk-snapshot"
+  namespace = "default"
+}
@@ -0,0 +1,20 @@
+data "harvester_volumes" "orphans" {
+  namespace  = "default"
+  unattached = true
+  older_than = "720h"
+}
+
+data "harvester_volumes" "ssd" {
+  storage_class_name = "harvester-longhorn-ssd"
+
+  tags = {
+    team = "data"
+  }
+}
+
+output "orphan_volumes" {
+  value = {
+    for volume in data.harvester_volumes.orphans.volumes :
+    volume.id => volume.last_attached_vm
+  }
+}
@@ -21,3 +21,13 @@ provider "harvester" {
 
   kubecontext = "mycontext"
 }
+
+# Harvester clusters imported into Rancher can be reached through Rancher's
+# cluster proxy instead of a per-cluster kubeconfig.
+provider "harvester" {
+  alias = "rancher"
+
+  rancher_url   = "https://rancher.example.com"
+  rancher_token = var.rancher_token
+  cluster_id    = "c-m-abcd1234"
+}
@@ -0,0 +1,15 @@
+resource "harvester_api_token" "ci" {
+  api_url  = "https://192.168.0.131"
+  password = var.admin_password
+
+  description = "CI pipeline"
+  ttl         = "720h"
+  cluster_id  = "local"
+
+  ca_certificate = file("harvester-ca.pem")
+}
+
+output "ci_token" {
+  value     = harvester_api_token.ci.token
+  sensitive = true
+}
@@ -0,0 +1,16 @@
+resource "harvester_bootstrap" "this" {
+  api_url  = "https://192.168.0.131"
+  password = var.admin_password
+
+  # a new cluster serves a self-signed certificate, set ca_certificate instead
+  # once it is signed by a CA
+  insecure = true
+
+  write_kubeconfig_file = false
+
+  initial_settings = {
+    "server-url"    = "https://harvester.example.com"
+    "ui-source"     = "bundled"
+    "telemetry-opt" = "out"
+  }
+}
@@ -0,0 +1 @@
+terraform import harvester_longhorn_recurring_job.foo <Name>
\ No newline at end of file
@@ -0,0 +1,35 @@
+resource "harvester_longhorn_recurring_job" "daily-backup" {
+  name = "daily-backup"
+
+  task        = "backup"
+  cron        = "0 2 * * *"
+  retain      = 7
+  concurrency = 2
+  groups      = ["daily-backup"]
+
+  snapshot_labels = {
+    schedule = "daily"
+  }
+}
+
+resource "harvester_longhorn_recurring_job" "hourly-snapshot" {
+  name = "hourly-snapshot"
+
+  task   = "snapshot"
+  cron   = "0 * * * *"
+  retain = 24
+  groups = ["default"]
+}
+
+resource "harvester_storageclass" "daily-backup" {
+  name = "daily-backup"
+
+  longhorn {
+    number_of_replicas = 3
+
+    recurring_job_selector {
+      name     = harvester_longhorn_recurring_job.daily-backup.groups[0]
+      is_group = true
+    }
+  }
+}
@@ -19,6 +19,26 @@ resource "harvester_storageclass" "ssd-replicas-3" {
   }
 }
 
+resource "harvester_storageclass" "ssd-encrypted" {
+  name = "ssd-encrypted"
+
+  longhorn {
+    number_of_replicas    = 3
+    stale_replica_timeout = 30
+    disk_selector         = ["ssd", "nvme"]
+    data_locality         = "best-effort"
+
+    encrypted                   = true
+    encryption_secret_name      = "encryption"
+    encryption_secret_namespace = "longhorn-system"
+
+    recurring_job_selector {
+      name     = "daily-backup"
+      is_group = true
+    }
+  }
+}
+
 resource "harvester_storageclass" "lvm" {
   name = "lvm"
 
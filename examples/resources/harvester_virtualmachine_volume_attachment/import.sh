@@ -0,0 +1 @@
+terraform import harvester_virtualmachine_volume_attachment.foo <Namespace>/<VirtualMachineName>/<DiskName>
\ No newline at end of file
@@ -0,0 +1,15 @@
+resource "harvester_virtualmachine_volume_attachment" "ubuntu20-data" {
+  namespace            = "default"
+  virtual_machine_name = harvester_virtualmachine.ubuntu20.name
+  volume_name          = harvester_volume.mount-disk.name
+}
+
+resource "harvester_virtualmachine_volume_attachment" "ubuntu20-data-cold" {
+  namespace            = "default"
+  virtual_machine_name = harvester_virtualmachine.ubuntu20.name
+  volume_name          = harvester_volume.mount-ssd-3-disk.name
+
+  disk_name = "data-cold"
+  bus       = "virtio"
+  hot_plug  = false
+}
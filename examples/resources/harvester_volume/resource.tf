@@ -37,4 +37,27 @@ resource "harvester_volume" "opensuse154-image-disk" {
 
   size  = "10Gi"
   image = harvester_image.opensuse154.id
-}
\ No newline at end of file
+}
+resource "harvester_volume" "mount-disk-restored" {
+  name      = "mount-disk-restored"
+  namespace = "default"
+
+  size            = "10Gi"
+  source_snapshot = harvester_volume_snapshot.mount-disk-snapshot.name
+}
+
+resource "harvester_volume" "mount-disk-clone" {
+  name      = "mount-disk-clone"
+  namespace = "default"
+
+  size          = "10Gi"
+  source_volume = harvester_volume.mount-disk.name
+}
+
+resource "harvester_volume" "mount-disk-backed-up" {
+  name      = "mount-disk-backed-up"
+  namespace = "default"
+
+  size                 = "10Gi"
+  recurring_job_groups = [harvester_longhorn_recurring_job.daily-backup.groups[0]]
+}
@@ -0,0 +1 @@
+terraform import harvester_volume_snapshot.foo <Namespace>/<Name>
\ No newline at end of file
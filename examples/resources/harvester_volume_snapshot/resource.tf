@@ -0,0 +1,6 @@
+resource "harvester_volume_snapshot" "mount-disk-snapshot" {
+  name      = "mount-disk-snapshot"
+  namespace = "default"
+
+  volume_name = harvester_volume.mount-disk.name
+}
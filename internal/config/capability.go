@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Capability is a feature which needs a minimum Harvester version, it is the
+// version whose release notes introduce the feature. Features which every
+// supported version has have none, e.g. the upload image source type, since
+// CheckVersion rejects the versions before v1.1.0, which all accept uploads.
+type Capability struct {
+	Name       string
+	MinVersion string
+}
+
+var (
+	CapabilityCDIImageBackend = Capability{
+		Name:       "the cdi image backend",
+		MinVersion: "v1.5.0",
+	}
+	CapabilityCPUPinning = Capability{
+		Name:       "CPU pinning",
+		MinVersion: "v1.3.0",
+	}
+	CapabilityIsolateEmulatorThread = Capability{
+		Name:       "isolate emulator thread",
+		MinVersion: "v1.4.0",
+	}
+	CapabilityVirtualMachineRequests = Capability{
+		Name:       "virtual machine resource requests",
+		MinVersion: "v1.4.0",
+	}
+)
+
+// CheckCapability returns an error if the Harvester server version is older
+// than the minimum version of the capability. Nothing is checked if the
+// version is unknown, or isn't a release version, e.g. master-head.
+func (c *Config) CheckCapability(capability Capability) error {
+	if c.version == nil {
+		return nil
+	}
+	if c.version.AtLeast(version.MustParseGeneric(capability.MinVersion)) {
+		return nil
+	}
+	return fmt.Errorf("%s requires Harvester %s or later, the current Harvester server version is %s", capability.Name, capability.MinVersion, c.serverVersion)
+}
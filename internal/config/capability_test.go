@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestCheckCapability(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverVersion string
+		capability    Capability
+		wantErr       bool
+	}{
+		{
+			name:          "older version",
+			serverVersion: "v1.2.1",
+			capability:    CapabilityCPUPinning,
+			wantErr:       true,
+		},
+		{
+			name:          "minimum version",
+			serverVersion: "v1.3.0",
+			capability:    CapabilityCPUPinning,
+		},
+		{
+			name:          "newer version",
+			serverVersion: "v1.6.1",
+			capability:    CapabilityCDIImageBackend,
+		},
+		{
+			name:          "pre-release of the minimum version",
+			serverVersion: "v1.5.0-rc1",
+			capability:    CapabilityCDIImageBackend,
+		},
+		{
+			name:          "head of an older version",
+			serverVersion: "v1.4-head",
+			capability:    CapabilityCDIImageBackend,
+			wantErr:       true,
+		},
+		{
+			name:          "unknown version",
+			serverVersion: "master-head",
+			capability:    CapabilityCDIImageBackend,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{serverVersion: tt.serverVersion}
+			c.version, _ = version.ParseGeneric(tt.serverVersion)
+			if err := c.CheckCapability(tt.capability); (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
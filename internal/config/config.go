@@ -2,24 +2,53 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
 
 	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
 )
 
 type Config struct {
 	Bootstrap   bool
 	KubeConfig  string
 	KubeContext string
-	k8sClient   *client.Client
+
+	RancherURL           string
+	RancherToken         string
+	ClusterID            string
+	RancherCACertificate string
+	RancherInsecure      bool
+
+	DefaultTags      map[string]string
+	DefaultLabels    map[string]string
+	DefaultNamespace string
+
+	ReadOnly          bool
+	AllowedNamespaces []string
+
+	serverVersion string
+	version       *version.Version
+
+	k8sClient *client.Client
 }
 
 func (c *Config) K8sClient() (*client.Client, error) {
 	if c.k8sClient == nil {
-		k8sClient, err := client.NewClient(c.KubeConfig, c.KubeContext)
+		var (
+			k8sClient *client.Client
+			err       error
+		)
+		if c.RancherURL != "" {
+			k8sClient, err = client.NewClientFromRancher(c.RancherURL, c.RancherToken, c.ClusterID, c.RancherCACertificate, c.RancherInsecure)
+		} else {
+			k8sClient, err = client.NewClient(c.KubeConfig, c.KubeContext)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -28,20 +57,66 @@ func (c *Config) K8sClient() (*client.Client, error) {
 	return c.k8sClient, nil
 }
 
-func (c *Config) CheckVersion() error {
+// Namespace returns the namespace used by resources that don't set one.
+func (c *Config) Namespace() string {
+	if c.DefaultNamespace != "" {
+		return c.DefaultNamespace
+	}
+	return constants.NamespaceDefault
+}
+
+// CheckWritable returns an error if the provider must not change the
+// resource, either because it is read only or because the namespace is not
+// in the allowed namespaces. An empty namespace is used by cluster scoped
+// resources, which are only guarded by read only mode.
+func (c *Config) CheckWritable(namespace string) error {
+	if c.ReadOnly {
+		return errors.New("the provider is configured with read_only = true, changes are not allowed")
+	}
+	return c.CheckNamespaceAllowed(namespace)
+}
+
+// CheckNamespaceAllowed returns an error if allowed namespaces are configured
+// and the namespace is not one of them.
+func (c *Config) CheckNamespaceAllowed(namespace string) error {
+	if namespace == "" || len(c.AllowedNamespaces) == 0 {
+		return nil
+	}
+	for _, allowed := range c.AllowedNamespaces {
+		if namespace == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("namespace %q is not in the provider allowed_namespaces %v", namespace, c.AllowedNamespaces)
+}
+
+// CheckVersion reads the Harvester server version once, rejects the versions
+// which are not supported and keeps it for the capability checks. The version
+// stays unknown if it can't be read, e.g. the kubeconfig is created by the
+// same apply or the credentials can't read the settings, or if it can't be
+// parsed, e.g. master-head. Then the capability checks are skipped and the API
+// reports unsupported features itself.
+func (c *Config) CheckVersion(ctx context.Context) error {
 	client, err := c.K8sClient()
 	if err != nil {
-		return err
+		tflog.Warn(ctx, fmt.Sprintf("skipping the Harvester version check: %v", err))
+		return nil
 	}
 
 	// check harvester version from settings
-	serverVersion, err := client.HarvesterClient.HarvesterhciV1beta1().Settings().Get(context.Background(), "server-version", metav1.GetOptions{})
+	serverVersion, err := client.HarvesterClient.HarvesterhciV1beta1().Settings().Get(ctx, constants.SettingServerVersion, metav1.GetOptions{})
 	if err != nil {
-		return err
+		tflog.Warn(ctx, fmt.Sprintf("skipping the Harvester version check: %v", err))
+		return nil
 	}
 	// harvester version v1.0-head, v1.0.2, v1.0.3 is not supported
 	if strings.HasPrefix(serverVersion.Value, "v1.0") {
 		return fmt.Errorf("current Harvester server version is %s, the minimum supported version is v1.1.0", serverVersion.Value)
 	}
+	c.serverVersion = serverVersion.Value
+	// the pre-release part is ignored, so v1.5-head and v1.5.0-rc1 count as v1.5.0
+	if c.version, err = version.ParseGeneric(serverVersion.Value); err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("unknown Harvester server version %s, skipping the capability checks", serverVersion.Value))
+	}
 	return nil
 }
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func TestCheckWritable(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		namespace string
+		wantErr   bool
+	}{
+		{
+			name:      "no guards",
+			config:    &Config{},
+			namespace: "default",
+		},
+		{
+			name:    "read only cluster scoped resource",
+			config:  &Config{ReadOnly: true},
+			wantErr: true,
+		},
+		{
+			name:      "read only namespaced resource",
+			config:    &Config{ReadOnly: true, AllowedNamespaces: []string{"default"}},
+			namespace: "default",
+			wantErr:   true,
+		},
+		{
+			name:      "allowed namespace",
+			config:    &Config{AllowedNamespaces: []string{"team-a", "team-b"}},
+			namespace: "team-b",
+		},
+		{
+			name:      "namespace not allowed",
+			config:    &Config{AllowedNamespaces: []string{"team-a"}},
+			namespace: "default",
+			wantErr:   true,
+		},
+		{
+			name:   "cluster scoped resource with allowed namespaces",
+			config: &Config{AllowedNamespaces: []string{"team-a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.CheckWritable(tt.namespace); (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		serverVersion string
+		wantVersion   string
+		wantErr       bool
+	}{
+		{
+			name:          "release version",
+			status:        http.StatusOK,
+			serverVersion: "v1.4.1",
+			wantVersion:   "1.4.1",
+		},
+		{
+			name:          "unknown version",
+			status:        http.StatusOK,
+			serverVersion: "master-head",
+		},
+		{
+			name:          "unsupported version",
+			status:        http.StatusOK,
+			serverVersion: "v1.0.3",
+			wantErr:       true,
+		},
+		{
+			name:   "settings forbidden",
+			status: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/apis/harvesterhci.io/v1beta1/settings/"+constants.SettingServerVersion {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusOK {
+					_ = json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Code: int32(tt.status), Reason: metav1.StatusReasonForbidden})
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"apiVersion": "harvesterhci.io/v1beta1",
+					"kind":       "Setting",
+					"metadata":   map[string]string{"name": constants.SettingServerVersion},
+					"value":      tt.serverVersion,
+				})
+			}))
+			defer server.Close()
+			k8sClient, err := client.NewClientForConfig(&rest.Config{Host: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := &Config{k8sClient: k8sClient}
+			if err = c.CheckVersion(context.Background()); (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			actual := ""
+			if c.version != nil {
+				actual = c.version.String()
+			}
+			if actual != tt.wantVersion {
+				t.Errorf("expected the version %q, got %q", tt.wantVersion, actual)
+			}
+		})
+	}
+}
+
+// TestCheckVersionWithoutKubeConfig expects the configure to continue without
+// a version when the kubeconfig is written by the same apply.
+func TestCheckVersionWithoutKubeConfig(t *testing.T) {
+	c := &Config{KubeConfig: filepath.Join(t.TempDir(), "kubeconfig.yaml")}
+	if err := c.CheckVersion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.version != nil {
+		t.Errorf("expected no version, got %s", c.version)
+	}
+	if err := c.CheckCapability(CapabilityCDIImageBackend); err != nil {
+		t.Errorf("expected the capability check to be skipped, got %v", err)
+	}
+}
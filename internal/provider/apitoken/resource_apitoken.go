@@ -0,0 +1,244 @@
+package apitoken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/harvester/terraform-provider-harvester/internal/provider/bootstrap"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+const (
+	apiTokenSessionTTL  = 60000
+	apiTokenSessionDesc = "Terraform API token session"
+)
+
+type tokenRequestPayload struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	TTL         int64  `json:"ttl,omitempty"`
+	ClusterID   string `json:"clusterId,omitempty"`
+}
+
+type tokenResponsePayload struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expiresAt"`
+	Expired   bool   `json:"expired"`
+}
+
+func ResourceAPIToken() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceAPITokenCreate,
+		ReadContext:   resourceAPITokenRead,
+		UpdateContext: resourceAPITokenUpdate,
+		DeleteContext: resourceAPITokenDelete,
+		Schema:        Schema(),
+		Timeouts: &schema.ResourceTimeout{
+			Create:  schema.DefaultTimeout(2 * time.Minute),
+			Read:    schema.DefaultTimeout(2 * time.Minute),
+			Update:  schema.DefaultTimeout(2 * time.Minute),
+			Delete:  schema.DefaultTimeout(2 * time.Minute),
+			Default: schema.DefaultTimeout(2 * time.Minute),
+		},
+	}
+}
+
+func resourceAPITokenCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiURL, err := getAPIURL(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	caCert, serverName, insecure := getTLSOptions(d)
+
+	payload := tokenRequestPayload{
+		Type:        "token",
+		Description: d.Get(constants.FieldAPITokenDescription).(string),
+		ClusterID:   d.Get(constants.FieldAPITokenClusterID).(string),
+	}
+	if ttl := d.Get(constants.FieldAPITokenTTL).(string); ttl != "" {
+		duration, err := time.ParseDuration(ttl)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		payload.TTL = duration.Milliseconds()
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to marshal token data: %v", err))
+	}
+
+	var token tokenResponsePayload
+	err = withSession(apiURL, d, func(session string) error {
+		resp, err := util.DoPost(apiURL+"/v3/tokens", string(data), caCert, serverName, insecure, map[string]string{
+			"Accept":        "application/json",
+			"Content-Type":  "application/json",
+			"Authorization": fmt.Sprintf("Bearer %s", session),
+		})
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("failed to create token, status code %d", resp.StatusCode)
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return fmt.Errorf("failed to decode token response: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if token.Token == "" {
+		return diag.Errorf("failed to create token, the response has no token")
+	}
+
+	d.SetId(token.ID)
+	if err = d.Set(constants.FieldAPITokenToken, token.Token); err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(setTokenStates(d, &token))
+}
+
+// resourceAPITokenRead reads the token with a login session of the user, the
+// token itself can't read it if it is scoped to a cluster. A token which is
+// gone or expired is removed from the state to be created again.
+func resourceAPITokenRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiURL, err := getAPIURL(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var (
+		token tokenResponsePayload
+		found bool
+	)
+	err = withSession(apiURL, d, func(session string) error {
+		token, found, err = getToken(apiURL, d.Id(), session, d)
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !found || token.Expired {
+		log.Printf("[INFO] Token %s is revoked or expired", d.Id())
+		d.SetId("")
+		return nil
+	}
+	return diag.FromErr(setTokenStates(d, &token))
+}
+
+func resourceAPITokenUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceAPITokenRead(ctx, d, meta)
+}
+
+// resourceAPITokenDelete revokes the token with a login session of the user.
+func resourceAPITokenDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiURL, err := getAPIURL(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err = withSession(apiURL, d, func(session string) error {
+		return revokeToken(apiURL, d.Id(), session, d)
+	}); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return nil
+}
+
+// withSession logs in with the username and password, calls f with the
+// short-lived session token, and revokes the session afterwards.
+func withSession(apiURL string, d *schema.ResourceData, f func(session string) error) error {
+	caCert, serverName, insecure := getTLSOptions(d)
+	sessionID, session, err := bootstrap.DoUserLogin(apiURL,
+		d.Get(constants.FieldAPITokenUsername).(string),
+		d.Get(constants.FieldAPITokenPassword).(string),
+		apiTokenSessionTTL, apiTokenSessionDesc, caCert, serverName, insecure)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := revokeToken(apiURL, sessionID, session, d); err != nil {
+			log.Printf("[WARN] Unable to revoke the session token %s: %v", sessionID, err)
+		}
+	}()
+	return f(session)
+}
+
+// getToken reads the token with the session, it reports whether the token
+// exists. Any other failure, including a rejected session, is an error.
+func getToken(apiURL, tokenID, session string, d *schema.ResourceData) (tokenResponsePayload, bool, error) {
+	var token tokenResponsePayload
+	caCert, serverName, insecure := getTLSOptions(d)
+	resp, err := util.DoGet(fmt.Sprintf("%s/v3/tokens/%s", apiURL, tokenID), "", "", session, caCert, serverName, insecure)
+	if err != nil {
+		return token, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return token, false, nil
+	default:
+		return token, false, fmt.Errorf("failed to get token %s, status code %d", tokenID, resp.StatusCode)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return token, false, fmt.Errorf("failed to decode token response: %v", err)
+	}
+	return token, true, nil
+}
+
+// revokeToken deletes the token with the session, a token which is already
+// gone is not an error.
+func revokeToken(apiURL, tokenID, session string, d *schema.ResourceData) error {
+	caCert, serverName, insecure := getTLSOptions(d)
+	resp, err := util.DoDelete(fmt.Sprintf("%s/v3/tokens/%s", apiURL, tokenID), session, caCert, serverName, insecure)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("failed to revoke token %s, status code %d", tokenID, resp.StatusCode)
+}
+
+func setTokenStates(d *schema.ResourceData, token *tokenResponsePayload) error {
+	name := token.Name
+	if name == "" {
+		// the token is <name>:<secret>
+		name, _, _ = strings.Cut(d.Get(constants.FieldAPITokenToken).(string), ":")
+	}
+	if err := d.Set(constants.FieldAPITokenName, name); err != nil {
+		return err
+	}
+	return d.Set(constants.FieldAPITokenExpiresAt, token.ExpiresAt)
+}
+
+func getAPIURL(d *schema.ResourceData) (string, error) {
+	u, err := url.Parse(d.Get(constants.FieldAPITokenAPIURL).(string))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+func getTLSOptions(d *schema.ResourceData) (string, string, bool) {
+	return d.Get(constants.FieldAPITokenCACertificate).(string),
+		d.Get(constants.FieldAPITokenTLSServerName).(string),
+		d.Get(constants.FieldAPITokenInsecure).(bool)
+}
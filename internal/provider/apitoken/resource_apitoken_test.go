@@ -0,0 +1,180 @@
+package apitoken
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+const testSession = "token-session:secret"
+
+// testServer serves the login with the password "password", and the token
+// token-ci to the session, cluster scoped tokens can't read themselves.
+func testServer(t *testing.T, tokenStatus int, token tokenResponsePayload, revoked *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v3-public/localProviders/local":
+			var login map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login["password"] != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": "token-session", "type": "token", "token": testSession})
+		case r.Header.Get("Authorization") != "Bearer "+testSession:
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodGet && r.URL.Path == "/v3/tokens/token-ci":
+			w.WriteHeader(tokenStatus)
+			if tokenStatus == http.StatusOK {
+				_ = json.NewEncoder(w).Encode(token)
+			}
+		case r.Method == http.MethodDelete:
+			*revoked = append(*revoked, r.URL.Path[len("/v3/tokens/"):])
+			w.WriteHeader(tokenStatus)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func testResourceData(t *testing.T, apiURL, password string) *schema.ResourceData {
+	d := schema.TestResourceDataRaw(t, Schema(), map[string]interface{}{
+		constants.FieldAPITokenAPIURL:    apiURL,
+		constants.FieldAPITokenPassword:  password,
+		constants.FieldAPITokenClusterID: "local",
+	})
+	d.SetId("token-ci")
+	if err := d.Set(constants.FieldAPITokenToken, "token-ci:secret"); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestResourceAPITokenRead(t *testing.T) {
+	tests := []struct {
+		name      string
+		password  string
+		status    int
+		token     tokenResponsePayload
+		id        string
+		expiresAt string
+		wantErr   bool
+	}{
+		{
+			name:      "token",
+			password:  "password",
+			status:    http.StatusOK,
+			token:     tokenResponsePayload{ID: "token-ci", Name: "token-ci", ExpiresAt: "2026-12-01T00:00:00Z"},
+			id:        "token-ci",
+			expiresAt: "2026-12-01T00:00:00Z",
+		},
+		{
+			name:     "expired token",
+			password: "password",
+			status:   http.StatusOK,
+			token:    tokenResponsePayload{ID: "token-ci", Name: "token-ci", Expired: true},
+		},
+		{
+			name:     "revoked token",
+			password: "password",
+			status:   http.StatusNotFound,
+		},
+		{
+			name:     "invalid password",
+			password: "wrong",
+			id:       "token-ci",
+			wantErr:  true,
+		},
+		{
+			name:     "forbidden",
+			password: "password",
+			status:   http.StatusForbidden,
+			id:       "token-ci",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revoked []string
+			server := testServer(t, tt.status, tt.token, &revoked)
+			defer server.Close()
+
+			d := testResourceData(t, server.URL, tt.password)
+			diags := resourceAPITokenRead(context.Background(), d, nil)
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, diags)
+			}
+			if d.Id() != tt.id {
+				t.Errorf("expected ID %q, got %q", tt.id, d.Id())
+			}
+			if expiresAt := d.Get(constants.FieldAPITokenExpiresAt).(string); tt.id != "" && !tt.wantErr && expiresAt != tt.expiresAt {
+				t.Errorf("expected expires_at %q, got %q", tt.expiresAt, expiresAt)
+			}
+		})
+	}
+}
+
+func TestResourceAPITokenDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		status   int
+		revoked  []string
+		wantErr  bool
+	}{
+		{
+			name:     "revoke",
+			password: "password",
+			status:   http.StatusNoContent,
+			revoked:  []string{"token-ci", "token-session"},
+		},
+		{
+			name:     "already revoked",
+			password: "password",
+			status:   http.StatusNotFound,
+			revoked:  []string{"token-ci", "token-session"},
+		},
+		{
+			name:     "invalid password",
+			password: "wrong",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revoked []string
+			server := testServer(t, tt.status, tokenResponsePayload{}, &revoked)
+			defer server.Close()
+
+			d := testResourceData(t, server.URL, tt.password)
+			diags := resourceAPITokenDelete(context.Background(), d, nil)
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, diags)
+			}
+			if !reflect.DeepEqual(revoked, tt.revoked) {
+				t.Errorf("expected %v to be revoked, got %v", tt.revoked, revoked)
+			}
+			if (d.Id() == "") == tt.wantErr {
+				t.Errorf("unexpected ID %q", d.Id())
+			}
+		})
+	}
+}
+
+func TestSetTokenStates(t *testing.T) {
+	d := testResourceData(t, "https://harvester.example.com", "password")
+	if err := setTokenStates(d, &tokenResponsePayload{ExpiresAt: "2026-12-01T00:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	if name := d.Get(constants.FieldAPITokenName).(string); name != "token-ci" {
+		t.Errorf("expected the name of the token token-ci, got %q", name)
+	}
+}
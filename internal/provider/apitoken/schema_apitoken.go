@@ -0,0 +1,88 @@
+package apitoken
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func Schema() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		constants.FieldAPITokenAPIURL: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: validation.NoZeroValues,
+			Description:  "API URL in the harvester",
+		},
+		constants.FieldAPITokenUsername: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			Default:      "admin",
+			ValidateFunc: validation.NoZeroValues,
+			Description:  "User the token is created for",
+		},
+		constants.FieldAPITokenPassword: {
+			Type:         schema.TypeString,
+			Required:     true,
+			Sensitive:    true,
+			ValidateFunc: validation.NoZeroValues,
+			Description:  "Password of the user, it is used to create, read and revoke the token with a short-lived session. Update it after the password of the user changed",
+		},
+		constants.FieldAPITokenDescription: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Description of the token",
+		},
+		constants.FieldAPITokenTTL: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ValidateFunc: util.IsPositiveDuration,
+			Description:  "Time to live of the token, e.g. 720h. The token doesn't expire when it is not set, unless Harvester limits the TTL of tokens",
+		},
+		constants.FieldAPITokenClusterID: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Scope the token to a cluster, e.g. `local`",
+		},
+		constants.FieldAPITokenCACertificate: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "PEM encoded CA certificate used to verify the Harvester server certificate, in addition to the system CAs",
+		},
+		constants.FieldAPITokenInsecure: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Skip the TLS verification of the Harvester server certificate",
+		},
+		constants.FieldAPITokenTLSServerName: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Server name used to verify the Harvester server certificate, e.g. when `api_url` uses the VIP address",
+		},
+		constants.FieldAPITokenName: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the token",
+		},
+		constants.FieldAPITokenToken: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "The bearer token",
+		},
+		constants.FieldAPITokenExpiresAt: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Expiry time of the token in RFC3339 format, empty if it never expires. An expired token is created again by the next apply",
+		},
+	}
+	return s
+}
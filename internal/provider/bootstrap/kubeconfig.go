@@ -0,0 +1,166 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/mitchellh/go-homedir"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+const (
+	// kubeconfigRenewBefore is how long before the expiry of its token the
+	// kubeconfig is generated again.
+	kubeconfigRenewBefore = time.Hour
+)
+
+type tokenResponsePayload struct {
+	ExpiresAt string `json:"expiresAt"`
+	Expired   bool   `json:"expired"`
+}
+
+func generateKubeconfig(apiURL, token string, tls tlsOptions) (string, error) {
+	log.Printf("Doing generate kubeconfig")
+	genKubeConfigURL := fmt.Sprintf("%s/%s", apiURL, "v1/management.cattle.io.clusters/local?action=generateKubeconfig")
+	genKubeConfigResp, err := util.DoPost(genKubeConfigURL, "", tls.CACertificate, tls.ServerName, tls.Insecure, map[string]string{"Authorization": fmt.Sprintf("Bearer %s", token)})
+	if err != nil {
+		return "", err
+	}
+	defer genKubeConfigResp.Body.Close()
+	if genKubeConfigResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to generate kubeconfig, status code %d", genKubeConfigResp.StatusCode)
+	}
+
+	var generateKubeConfigResponseData generateKubeConfigResponsePayload
+	if err := json.NewDecoder(genKubeConfigResp.Body).Decode(&generateKubeConfigResponseData); err != nil {
+		return "", fmt.Errorf("failed to decode generate kubeconfig response: %v", err)
+	}
+	if generateKubeConfigResponseData.Config == "" {
+		return "", fmt.Errorf("failed to generate kubeconfig")
+	}
+	return generateKubeConfigResponseData.Config, nil
+}
+
+// kubeconfigExpiresAt returns when the token of the kubeconfig expires, the
+// zero time if it never expires. An error is returned if the token can't be
+// used anymore.
+func kubeconfigExpiresAt(apiURL, kubeconfig string, tls tlsOptions) (time.Time, error) {
+	token, err := kubeconfigToken(kubeconfig)
+	if err != nil {
+		return time.Time{}, err
+	}
+	tokenName := tokenNameOf(token)
+
+	tokenResp, err := util.DoGet(fmt.Sprintf("%s/v3/tokens/%s", apiURL, tokenName), "", "", token, tls.CACertificate, tls.ServerName, tls.Insecure)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tokenResp.Body.Close()
+	if tokenResp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("failed to get the kubeconfig token, status code %d", tokenResp.StatusCode)
+	}
+
+	var tokenResponseData tokenResponsePayload
+	if err := json.NewDecoder(tokenResp.Body).Decode(&tokenResponseData); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode token response: %v", err)
+	}
+	if tokenResponseData.Expired {
+		return time.Time{}, fmt.Errorf("the kubeconfig token %s is expired", tokenName)
+	}
+	if tokenResponseData.ExpiresAt == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, tokenResponseData.ExpiresAt)
+}
+
+func kubeconfigToken(kubeconfig string) (string, error) {
+	clientConfig, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return "", err
+	}
+	kubeContext, ok := clientConfig.Contexts[clientConfig.CurrentContext]
+	if !ok {
+		return "", fmt.Errorf("current context %q not found in the kubeconfig", clientConfig.CurrentContext)
+	}
+	authInfo, ok := clientConfig.AuthInfos[kubeContext.AuthInfo]
+	if !ok || authInfo.Token == "" {
+		return "", fmt.Errorf("token of user %q not found in the kubeconfig", kubeContext.AuthInfo)
+	}
+	return authInfo.Token, nil
+}
+
+// tokenNameOf returns the name of a token, which is <name>:<secret>.
+func tokenNameOf(token string) string {
+	tokenName, _, _ := strings.Cut(token, ":")
+	return tokenName
+}
+
+// revokeKubeconfigToken revokes the token of a kubeconfig which is replaced
+// by a renewed one, with the session token which generated the new one.
+func revokeKubeconfigToken(apiURL, oldKubeconfig, newKubeconfig, token string, tls tlsOptions) error {
+	if oldKubeconfig == "" {
+		return nil
+	}
+	oldToken, err := kubeconfigToken(oldKubeconfig)
+	if err != nil {
+		return err
+	}
+	// the same token is returned if Harvester reuses it for the new kubeconfig
+	if newToken, err := kubeconfigToken(newKubeconfig); err == nil && tokenNameOf(newToken) == tokenNameOf(oldToken) {
+		return nil
+	}
+	return revokeToken(apiURL, tokenNameOf(oldToken), token, tls)
+}
+
+// kubeconfigNeedsRenew reports whether the kubeconfig must be generated again.
+func kubeconfigNeedsRenew(apiURL, kubeconfig string, tls tlsOptions) (bool, time.Time) {
+	if kubeconfig == "" {
+		return true, time.Time{}
+	}
+	expiresAt, err := kubeconfigExpiresAt(apiURL, kubeconfig, tls)
+	if err != nil {
+		log.Printf("[INFO] Renewing the kubeconfig: %v", err)
+		return true, time.Time{}
+	}
+	if !expiresAt.IsZero() && time.Until(expiresAt) < kubeconfigRenewBefore {
+		log.Printf("[INFO] Renewing the kubeconfig, it expires at %s", expiresAt.Format(time.RFC3339))
+		return true, time.Time{}
+	}
+	return false, expiresAt
+}
+
+// setKubeconfig stores the kubeconfig in the state, and writes it to the
+// kubeconfig path if write_kubeconfig_file is set.
+func setKubeconfig(d *schema.ResourceData, kubeconfig string, expiresAt time.Time) error {
+	if err := d.Set(constants.FieldBootstrapKubeConfigContent, kubeconfig); err != nil {
+		return err
+	}
+	var expiresAtValue string
+	if !expiresAt.IsZero() {
+		expiresAtValue = expiresAt.Format(time.RFC3339)
+	}
+	if err := d.Set(constants.FieldBootstrapKubeConfigExpiresAt, expiresAtValue); err != nil {
+		return err
+	}
+
+	if !d.Get(constants.FieldBootstrapWriteKubeConfigFile).(bool) {
+		return nil
+	}
+	kubeconfigPath, err := homedir.Expand(d.Get(constants.FieldBootstrapKubeConfig).(string))
+	if err != nil {
+		return err
+	}
+	if current, err := os.ReadFile(kubeconfigPath); err == nil && string(current) == kubeconfig {
+		return nil
+	}
+	return os.WriteFile(kubeconfigPath, []byte(kubeconfig), 0600)
+}
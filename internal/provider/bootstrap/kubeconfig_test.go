@@ -0,0 +1,132 @@
+package bootstrap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testKubeconfig(token string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: local
+  cluster:
+    server: https://harvester.example.com/k8s/clusters/local
+contexts:
+- name: local
+  context:
+    cluster: local
+    user: local
+current-context: local
+users:
+- name: local
+  user:
+    token: %s
+`, token)
+}
+
+func TestKubeconfigToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubeconfig string
+		token      string
+		wantErr    bool
+	}{
+		{
+			name:       "token",
+			kubeconfig: testKubeconfig("kubeconfig-user-abc:secret"),
+			token:      "kubeconfig-user-abc:secret",
+		},
+		{
+			name:       "without token",
+			kubeconfig: testKubeconfig(`""`),
+			wantErr:    true,
+		},
+		{
+			name:       "invalid kubeconfig",
+			kubeconfig: "{",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := kubeconfigToken(tt.kubeconfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if token != tt.token {
+				t.Errorf("expected token %q, got %q", tt.token, token)
+			}
+			if name := tokenNameOf(token); err == nil && name != "kubeconfig-user-abc" {
+				t.Errorf("expected token name kubeconfig-user-abc, got %q", name)
+			}
+		})
+	}
+}
+
+func TestRevokeKubeconfigToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		oldKubeconfig string
+		newKubeconfig string
+		status        int
+		revoked       string
+		wantErr       bool
+	}{
+		{
+			name:          "renewed token",
+			oldKubeconfig: testKubeconfig("kubeconfig-user-old:secret"),
+			newKubeconfig: testKubeconfig("kubeconfig-user-new:secret"),
+			status:        http.StatusNoContent,
+			revoked:       "/v3/tokens/kubeconfig-user-old",
+		},
+		{
+			name:          "already revoked token",
+			oldKubeconfig: testKubeconfig("kubeconfig-user-old:secret"),
+			newKubeconfig: testKubeconfig("kubeconfig-user-new:secret"),
+			status:        http.StatusNotFound,
+			revoked:       "/v3/tokens/kubeconfig-user-old",
+		},
+		{
+			name:          "reused token",
+			oldKubeconfig: testKubeconfig("kubeconfig-user-old:secret"),
+			newKubeconfig: testKubeconfig("kubeconfig-user-old:secret"),
+		},
+		{
+			name:          "first kubeconfig",
+			newKubeconfig: testKubeconfig("kubeconfig-user-new:secret"),
+		},
+		{
+			name:          "revoke fails",
+			oldKubeconfig: testKubeconfig("kubeconfig-user-old:secret"),
+			newKubeconfig: testKubeconfig("kubeconfig-user-new:secret"),
+			status:        http.StatusForbidden,
+			revoked:       "/v3/tokens/kubeconfig-user-old",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revoked string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodDelete || r.Header.Get("Authorization") != "Bearer session:secret" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				revoked = r.URL.Path
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := revokeKubeconfigToken(server.URL, tt.oldKubeconfig, tt.newKubeconfig, "session:secret", tlsOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if revoked != tt.revoked {
+				t.Errorf("expected %q to be revoked, got %q", tt.revoked, revoked)
+			}
+		})
+	}
+}
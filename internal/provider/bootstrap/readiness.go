@@ -0,0 +1,95 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+)
+
+const (
+	stateAPINotReady = "NotReady"
+	stateAPIReady    = "Ready"
+)
+
+var (
+	// ErrAPINotReady is returned while the Rancher auth endpoints of a new
+	// Harvester cluster are not served yet, the request can be retried.
+	ErrAPINotReady = errors.New("the Harvester API is not ready yet")
+	// ErrInvalidCredentials is returned if the login is rejected.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+)
+
+// classifyResponse maps the result of a request to the Rancher auth endpoints
+// to ErrAPINotReady or ErrInvalidCredentials if it is one of them.
+func classifyResponse(resp *http.Response, err error) error {
+	if err != nil {
+		if isCertificateError(err) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrAPINotReady, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w, status code %d", ErrInvalidCredentials, resp.StatusCode)
+	case http.StatusNotFound, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return fmt.Errorf("%w, status code %d", ErrAPINotReady, resp.StatusCode)
+	}
+	return nil
+}
+
+// isCertificateError reports whether the request failed to verify the server
+// certificate, which won't be fixed by waiting.
+func isCertificateError(err error) bool {
+	var (
+		verificationErr     *tls.CertificateVerificationError
+		unknownAuthorityErr x509.UnknownAuthorityError
+		hostnameErr         x509.HostnameError
+		invalidErr          x509.CertificateInvalidError
+	)
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
+
+// waitForAPIReady polls the public local auth provider of Harvester until it
+// is served. The interval backs off up to 10 seconds unless pollInterval is set.
+func waitForAPIReady(ctx context.Context, apiURL string, tlsOpts tlsOptions, timeout, pollInterval time.Duration) error {
+	readyURL := apiURL + "/v3-public/localProviders/local"
+	stateConf := &retry.StateChangeConf{
+		Pending: []string{stateAPINotReady},
+		Target:  []string{stateAPIReady},
+		Refresh: func() (interface{}, string, error) {
+			resp, err := util.DoGet(readyURL, "", "", "", tlsOpts.CACertificate, tlsOpts.ServerName, tlsOpts.Insecure)
+			if err == nil {
+				defer resp.Body.Close()
+			}
+			if err = classifyResponse(resp, err); err != nil {
+				if errors.Is(err, ErrAPINotReady) {
+					log.Printf("[INFO] Waiting for the Harvester API: %v", err)
+					return readyURL, stateAPINotReady, nil
+				}
+				return nil, "", err
+			}
+			if resp.StatusCode != http.StatusOK {
+				return nil, "", fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, readyURL)
+			}
+			return readyURL, stateAPIReady, nil
+		},
+		Timeout:      timeout,
+		PollInterval: pollInterval,
+	}
+	if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+		return fmt.Errorf("waiting for the Harvester API at %s to be ready: %w", apiURL, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClassifyResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		err      error
+		expected error
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "created", status: http.StatusCreated},
+		{name: "unauthorized", status: http.StatusUnauthorized, expected: ErrInvalidCredentials},
+		{name: "forbidden", status: http.StatusForbidden, expected: ErrInvalidCredentials},
+		{name: "not found", status: http.StatusNotFound, expected: ErrAPINotReady},
+		{name: "bad gateway", status: http.StatusBadGateway, expected: ErrAPINotReady},
+		{name: "service unavailable", status: http.StatusServiceUnavailable, expected: ErrAPINotReady},
+		{name: "connection refused", err: errors.New("connection refused"), expected: ErrAPINotReady},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			err := classifyResponse(resp, tt.err)
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestWaitForAPIReady(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+	}{
+		{
+			name:     "ready",
+			statuses: []int{http.StatusOK},
+		},
+		{
+			name:     "ready after restarts",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusNotFound, http.StatusOK},
+		},
+		{
+			name:     "unexpected status",
+			statuses: []int{http.StatusInternalServerError},
+			wantErr:  true,
+		},
+		{
+			name:     "never ready",
+			statuses: []int{http.StatusServiceUnavailable},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v3-public/localProviders/local" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				status := tt.statuses[len(tt.statuses)-1]
+				if requests < len(tt.statuses) {
+					status = tt.statuses[requests]
+				}
+				requests++
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			err := waitForAPIReady(context.Background(), server.URL, tlsOptions{}, 2*time.Second, 10*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
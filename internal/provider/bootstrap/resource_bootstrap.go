@@ -3,15 +3,15 @@ package bootstrap
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
-	"os"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/mitchellh/go-homedir"
 
 	"github.com/harvester/terraform-provider-harvester/internal/config"
 	"github.com/harvester/terraform-provider-harvester/internal/util"
@@ -20,7 +20,6 @@ import (
 
 const (
 	bootstrapDefaultUser        = "admin"
-	bootstrapDefaultTTL         = 60000
 	bootstrapDefaultSessionDesc = "Terraform bootstrap admin session"
 )
 
@@ -48,12 +47,35 @@ type generateKubeConfigResponsePayload struct {
 	Config string `json:"config"`
 }
 
+// tlsOptions configures how the Harvester server certificate is verified.
+type tlsOptions struct {
+	CACertificate string
+	ServerName    string
+	Insecure      bool
+}
+
+func getTLSOptions(d *schema.ResourceData) tlsOptions {
+	return tlsOptions{
+		CACertificate: d.Get(constants.FieldBootstrapCACertificate).(string),
+		ServerName:    d.Get(constants.FieldBootstrapTLSServerName).(string),
+		Insecure:      d.Get(constants.FieldBootstrapInsecure).(bool),
+	}
+}
+
 func ResourceBootstrap() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceBootstrapCreate,
 		ReadContext:   resourceBootstrapRead,
+		UpdateContext: resourceBootstrapUpdate,
 		DeleteContext: resourceBootstrapDelete,
 		Schema:        Schema(),
+		Timeouts: &schema.ResourceTimeout{
+			Create:  schema.DefaultTimeout(15 * time.Minute),
+			Read:    schema.DefaultTimeout(2 * time.Minute),
+			Update:  schema.DefaultTimeout(2 * time.Minute),
+			Delete:  schema.DefaultTimeout(2 * time.Minute),
+			Default: schema.DefaultTimeout(2 * time.Minute),
+		},
 	}
 }
 
@@ -69,13 +91,15 @@ func resourceBootstrapCreate(ctx context.Context, d *schema.ResourceData, meta i
 	}
 	apiURL := u.String()
 
-	kubeConfig, err := homedir.Expand(d.Get(constants.FieldProviderKubeConfig).(string))
-	if err != nil {
+	tls := getTLSOptions(d)
+
+	pollInterval := time.Duration(d.Get(constants.FieldBootstrapReadinessPollInterval).(int)) * time.Second
+	if err = waitForAPIReady(ctx, apiURL, tls, d.Timeout(schema.TimeoutCreate), pollInterval); err != nil {
 		return diag.FromErr(err)
 	}
 
 	// login to get token
-	tokenID, token, err := bootstrapLogin(apiURL, d, c)
+	tokenID, token, err := bootstrapLogin(apiURL, d, c, tls)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -94,7 +118,7 @@ func resourceBootstrapCreate(ctx context.Context, d *schema.ResourceData, meta i
 		if err != nil {
 			return diag.FromErr(fmt.Errorf("failed to marshal change password data: %v", err))
 		}
-		changePasswordResp, err := util.DoPost(changePasswordURL, string(changePasswordData), "", true, map[string]string{"Authorization": fmt.Sprintf("Bearer %s", token)})
+		changePasswordResp, err := util.DoPost(changePasswordURL, string(changePasswordData), tls.CACertificate, tls.ServerName, tls.Insecure, map[string]string{"Authorization": fmt.Sprintf("Bearer %s", token)})
 		if err != nil {
 			return diag.FromErr(err)
 		}
@@ -103,30 +127,29 @@ func resourceBootstrapCreate(ctx context.Context, d *schema.ResourceData, meta i
 		}
 	}
 
-	// get kubeconfig
-	log.Printf("Doing generate kubeconfig")
-	genKubeConfigURL := fmt.Sprintf("%s/%s", apiURL, "v1/management.cattle.io.clusters/local?action=generateKubeconfig")
-	genKubeConfigResp, err := util.DoPost(genKubeConfigURL, "", "", true, map[string]string{"Authorization": fmt.Sprintf("Bearer %s", token)})
-	if err != nil {
+	if err = applySettings(apiURL, token, tls, d.Get(constants.FieldBootstrapInitialSettings).(map[string]interface{})); err != nil {
 		return diag.FromErr(err)
 	}
-	if genKubeConfigResp.StatusCode != http.StatusOK {
-		return diag.Errorf("failed to generate kubeconfig, status code %d", genKubeConfigResp.StatusCode)
-	}
 
-	var generateKubeConfigResponseData generateKubeConfigResponsePayload
-	if err := json.NewDecoder(genKubeConfigResp.Body).Decode(&generateKubeConfigResponseData); err != nil {
-		return diag.FromErr(fmt.Errorf("failed to decode generate kubeconfig response: %v", err))
+	// get kubeconfig
+	kubeConfig, err := generateKubeconfig(apiURL, token, tls)
+	if err != nil {
+		return diag.FromErr(err)
 	}
-	if generateKubeConfigResponseData.Config == "" {
-		return diag.FromErr(fmt.Errorf("failed to generate kubeconfig"))
+	expiresAt, err := kubeconfigExpiresAt(apiURL, kubeConfig, tls)
+	if err != nil {
+		log.Printf("[WARN] Unable to get the expiry of the kubeconfig: %v", err)
 	}
-
-	// write kubeconfig
-	if err = os.WriteFile(kubeConfig, []byte(generateKubeConfigResponseData.Config), 0600); err != nil {
+	if err = setKubeconfig(d, kubeConfig, expiresAt); err != nil {
 		return diag.FromErr(err)
 	}
 
+	// the kubeconfig has its own token, the session isn't needed anymore
+	if d.Get(constants.FieldBootstrapRevokeSessionToken).(bool) {
+		if err = revokeToken(apiURL, tokenID, token, tls); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return nil
 }
 
@@ -142,70 +165,190 @@ func resourceBootstrapRead(ctx context.Context, d *schema.ResourceData, meta int
 	}
 	apiURL := u.String()
 
-	kubeConfig, err := homedir.Expand(d.Get(constants.FieldProviderKubeConfig).(string))
-	if err != nil {
-		return diag.FromErr(err)
+	tls := getTLSOptions(d)
+
+	// keep the kubeconfig until its token is about to expire
+	oldKubeConfig := d.Get(constants.FieldBootstrapKubeConfigContent).(string)
+	if renew, expiresAt := kubeconfigNeedsRenew(apiURL, oldKubeConfig, tls); !renew {
+		return diag.FromErr(setKubeconfig(d, oldKubeConfig, expiresAt))
 	}
 
 	// login to get token
-	_, token, err := bootstrapLogin(apiURL, d, c)
+	tokenID, token, err := bootstrapLogin(apiURL, d, c, tls)
 	if err != nil {
-		log.Printf("[INFO] Bootstrap is unable to login to Harvester")
-		d.SetId("")
+		// keep the resource while the cluster is temporarily unavailable
+		if !errors.Is(err, ErrAPINotReady) {
+			log.Printf("[INFO] Bootstrap is unable to login to Harvester")
+			d.SetId("")
+		}
 		return diag.FromErr(err)
 	}
 
-	log.Printf("Doing generate kubeconfig")
-	genKubeConfigURL := fmt.Sprintf("%s/%s", apiURL, "v1/management.cattle.io.clusters/local?action=generateKubeconfig")
-	genKubeConfigResp, err := util.DoPost(genKubeConfigURL, "", "", true, map[string]string{"Authorization": fmt.Sprintf("Bearer %s", token)})
+	kubeConfig, err := generateKubeconfig(apiURL, token, tls)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	if genKubeConfigResp.StatusCode != http.StatusOK {
-		return diag.Errorf("failed to generate kubeconfig, status code %d", genKubeConfigResp.StatusCode)
+	expiresAt, err := kubeconfigExpiresAt(apiURL, kubeConfig, tls)
+	if err != nil {
+		log.Printf("[WARN] Unable to get the expiry of the kubeconfig: %v", err)
+	}
+	if err = setKubeconfig(d, kubeConfig, expiresAt); err != nil {
+		return diag.FromErr(err)
 	}
 
-	var generateKubeConfigResponseData generateKubeConfigResponsePayload
-	if err := json.NewDecoder(genKubeConfigResp.Body).Decode(&generateKubeConfigResponseData); err != nil {
-		return diag.FromErr(fmt.Errorf("failed to decode generate kubeconfig response: %v", err))
+	var diags diag.Diagnostics
+	// the replaced kubeconfig must not stay usable until its token expires
+	if err = revokeKubeconfigToken(apiURL, oldKubeConfig, kubeConfig, token, tls); err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Unable to revoke the token of the replaced kubeconfig",
+			Detail:   err.Error(),
+		})
 	}
-	if generateKubeConfigResponseData.Config == "" {
-		return diag.FromErr(fmt.Errorf("failed to generate kubeconfig"))
+	// this session is only used to renew the kubeconfig
+	return append(diags, diag.FromErr(revokeToken(apiURL, tokenID, token, tls))...)
+}
+
+func resourceBootstrapUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if d.HasChange(constants.FieldBootstrapInitialSettings) {
+		if err := updateSettings(d, meta.(*config.Config)); err != nil {
+			return diag.FromErr(err)
+		}
 	}
+	return resourceBootstrapRead(ctx, d, meta)
+}
 
-	// write kubeconfig
-	if err = os.WriteFile(kubeConfig, []byte(generateKubeConfigResponseData.Config), 0600); err != nil {
-		return diag.FromErr(err)
+// updateSettings applies the initial_settings which are added or changed.
+func updateSettings(d *schema.ResourceData, c *config.Config) error {
+	u, err := url.Parse(d.Get(constants.FieldBootstrapAPIURL).(string))
+	if err != nil {
+		return err
 	}
-	return nil
+	apiURL := u.String()
+	tls := getTLSOptions(d)
+
+	oldSettings, newSettings := d.GetChange(constants.FieldBootstrapInitialSettings)
+	changed := map[string]interface{}{}
+	for name, value := range newSettings.(map[string]interface{}) {
+		if oldValue, ok := oldSettings.(map[string]interface{})[name]; !ok || oldValue != value {
+			changed[name] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	tokenID, token, err := bootstrapLogin(apiURL, d, c, tls)
+	if err != nil {
+		return err
+	}
+	if err = applySettings(apiURL, token, tls, changed); err != nil {
+		return err
+	}
+	return revokeToken(apiURL, tokenID, token, tls)
 }
 
+// resourceBootstrapDelete revokes the session token of the resource, the token
+// itself is not stored. It is revoked with the token of the kubeconfig, which
+// is renewed on read and so still valid, or else with a new session. The
+// cluster is often destroyed together with the resource, so failures are only
+// warnings.
 func resourceBootstrapDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	u, err := url.Parse(d.Get(constants.FieldBootstrapAPIURL).(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	apiURL := u.String()
+	tls := getTLSOptions(d)
+
+	err = errors.New("the kubeconfig has no token")
+	if token, tokenErr := kubeconfigToken(d.Get(constants.FieldBootstrapKubeConfigContent).(string)); tokenErr == nil {
+		err = revokeToken(apiURL, d.Id(), token, tls)
+	}
+	if err != nil {
+		log.Printf("[INFO] Unable to revoke the bootstrap session token with the kubeconfig token, logging in: %v", err)
+		var tokenID, token string
+		tokenID, token, err = deleteLogin(apiURL, d, tls)
+		if err == nil && d.Id() != tokenID {
+			err = revokeToken(apiURL, d.Id(), token, tls)
+		}
+		if err == nil {
+			err = revokeToken(apiURL, tokenID, token, tls)
+		}
+	}
 	d.SetId("")
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Unable to revoke the bootstrap session token",
+			Detail:   err.Error(),
+		}}
+	}
 	return nil
 }
 
-func bootstrapLogin(apiURL string, d *schema.ResourceData, c *config.Config) (string, string, error) {
+// deleteLogin logs in with the password, or with the initial_password if the
+// password was not changed by the resource.
+func deleteLogin(apiURL string, d *schema.ResourceData, tls tlsOptions) (string, string, error) {
+	ttl := bootstrapSessionTTL(d)
+	desc := d.Get(constants.FieldBootstrapSessionDescription).(string)
+	tokenID, token, err := DoUserLogin(apiURL, bootstrapDefaultUser, d.Get(constants.FieldBootstrapPassword).(string), ttl, desc, tls.CACertificate, tls.ServerName, tls.Insecure)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		return tokenID, token, err
+	}
+	return DoUserLogin(apiURL, bootstrapDefaultUser, d.Get(constants.FieldBootstrapInitialPassword).(string), ttl, desc, tls.CACertificate, tls.ServerName, tls.Insecure)
+}
+
+// revokeToken deletes the token with the given ID through the Rancher v3
+// tokens API, a token which is already gone is not an error.
+func revokeToken(apiURL, tokenID, token string, tls tlsOptions) error {
+	log.Printf("Doing revoke token %s", tokenID)
+	resp, err := util.DoDelete(fmt.Sprintf("%s/v3/tokens/%s", apiURL, tokenID), token, tls.CACertificate, tls.ServerName, tls.Insecure)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("failed to revoke token %s, status code %d", tokenID, resp.StatusCode)
+}
+
+// bootstrapSessionTTL returns the session_ttl in milliseconds, as expected by
+// the login API.
+func bootstrapSessionTTL(d *schema.ResourceData) int {
+	ttl, _ := time.ParseDuration(d.Get(constants.FieldBootstrapSessionTTL).(string))
+	return int(ttl.Milliseconds())
+}
+
+func bootstrapLogin(apiURL string, d *schema.ResourceData, c *config.Config, tls tlsOptions) (string, string, error) {
 	initialPassword := d.Get(constants.FieldBootstrapInitialPassword).(string)
 
 	log.Printf("Doing login with initial password")
-	tokenID, token, err := DoUserLogin(apiURL, bootstrapDefaultUser, initialPassword, bootstrapDefaultTTL, bootstrapDefaultSessionDesc, "", true)
+	tokenID, token, err := DoUserLogin(apiURL, bootstrapDefaultUser, initialPassword, bootstrapSessionTTL(d), d.Get(constants.FieldBootstrapSessionDescription).(string), tls.CACertificate, tls.ServerName, tls.Insecure)
 	if err == nil {
 		err = d.Set(constants.FieldShouldUpdatePassword, true)
 		return tokenID, token, err
 	}
+	if !errors.Is(err, ErrInvalidCredentials) {
+		return "", "", err
+	}
 
 	log.Printf("Doing login with password")
 	password := d.Get(constants.FieldBootstrapPassword).(string)
-	tokenID, token, err = DoUserLogin(apiURL, bootstrapDefaultUser, password, bootstrapDefaultTTL, bootstrapDefaultSessionDesc, "", true)
+	tokenID, token, err = DoUserLogin(apiURL, bootstrapDefaultUser, password, bootstrapSessionTTL(d), d.Get(constants.FieldBootstrapSessionDescription).(string), tls.CACertificate, tls.ServerName, tls.Insecure)
 	if err == nil {
 		err = d.Set(constants.FieldShouldUpdatePassword, false)
 		return tokenID, token, err
 	}
+	if errors.Is(err, ErrInvalidCredentials) {
+		return "", "", fmt.Errorf("neither initial_password nor password are accepted for the user %s: %w", bootstrapDefaultUser, err)
+	}
 	return "", "", err
 }
 
-func DoUserLogin(url, user, pass string, ttl int, desc, cacert string, insecure bool) (string, string, error) {
+func DoUserLogin(url, user, pass string, ttl int, desc, cacert, tlsServerName string, insecure bool) (string, string, error) {
 	loginURL := url + "/v3-public/localProviders/local?action=login"
 	loginData, err := json.Marshal(loginRequestPayload{ //nolint:gosec
 		Username:     user,
@@ -224,10 +367,11 @@ func DoUserLogin(url, user, pass string, ttl int, desc, cacert string, insecure
 	}
 
 	// Login with user and pass
-	loginResp, err := util.DoPost(loginURL, string(loginData), cacert, insecure, loginHead)
-	if err != nil {
+	loginResp, err := util.DoPost(loginURL, string(loginData), cacert, tlsServerName, insecure, loginHead)
+	if err = classifyResponse(loginResp, err); err != nil {
 		return "", "", err
 	}
+	defer loginResp.Body.Close()
 	if loginResp.StatusCode != http.StatusCreated {
 		return "", "", fmt.Errorf("can't login successfully, status code %d", loginResp.StatusCode)
 	}
@@ -0,0 +1,98 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func TestResourceBootstrapDelete(t *testing.T) {
+	tests := []struct {
+		name            string
+		kubeconfigValid bool
+		password        string
+		revoked         []string
+		warning         bool
+	}{
+		{
+			name:            "revoke with the kubeconfig token",
+			kubeconfigValid: true,
+			revoked:         []string{"token-create"},
+		},
+		{
+			name:     "revoke with the password",
+			password: "password",
+			revoked:  []string{"token-create", "token-delete"},
+		},
+		{
+			name:     "revoke with the initial password",
+			password: "admin",
+			revoked:  []string{"token-create", "token-delete"},
+		},
+		{
+			name:     "no valid credentials",
+			password: "rotated",
+			warning:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revoked []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/v3-public/localProviders/local":
+					var login loginRequestPayload
+					if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login.Password != tt.password {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					w.WriteHeader(http.StatusCreated)
+					_ = json.NewEncoder(w).Encode(loginResponsePayload{ID: "token-delete", Type: "token", Token: "token-delete:secret"})
+				case r.Method == http.MethodDelete:
+					auth := r.Header.Get("Authorization")
+					if (auth != "Bearer kubeconfig-user-abc:secret" || !tt.kubeconfigValid) && auth != "Bearer token-delete:secret" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					revoked = append(revoked, r.URL.Path[len("/v3/tokens/"):])
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			defer server.Close()
+
+			d := schema.TestResourceDataRaw(t, Schema(), map[string]interface{}{
+				constants.FieldBootstrapAPIURL:          server.URL,
+				constants.FieldBootstrapPassword:        "password",
+				constants.FieldBootstrapInitialPassword: "admin",
+				constants.FieldBootstrapInsecure:        true,
+			})
+			d.SetId("token-create")
+			if err := d.Set(constants.FieldBootstrapKubeConfigContent, testKubeconfig("kubeconfig-user-abc:secret")); err != nil {
+				t.Fatal(err)
+			}
+
+			diags := resourceBootstrapDelete(context.Background(), d, nil)
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if (len(diags) > 0) != tt.warning {
+				t.Errorf("expected warning=%v, got %v", tt.warning, diags)
+			}
+			if !reflect.DeepEqual(revoked, tt.revoked) {
+				t.Errorf("expected %v to be revoked, got %v", tt.revoked, revoked)
+			}
+			if d.Id() != "" {
+				t.Errorf("expected the ID to be cleared, got %q", d.Id())
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
+	"github.com/harvester/terraform-provider-harvester/internal/util"
 	"github.com/harvester/terraform-provider-harvester/pkg/constants"
 )
 
@@ -41,6 +42,70 @@ func Schema() map[string]*schema.Schema {
 			ValidateFunc: validation.NoZeroValues,
 			Description:  "Path to store the kubeconfig file",
 		},
+		constants.FieldBootstrapWriteKubeConfigFile: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			Description: "Write the kubeconfig to the `kubeconfig` path, disable it to only use `kube_config`",
+		},
+		constants.FieldBootstrapKubeConfigContent: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "Content of the generated kubeconfig, it is renewed when the token expires in less than an hour",
+		},
+		constants.FieldBootstrapKubeConfigExpiresAt: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Expiry time of the kubeconfig token in RFC3339 format, empty if it never expires",
+		},
+		constants.FieldBootstrapCACertificate: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "PEM encoded CA certificate used to verify the Harvester server certificate, in addition to the system CAs",
+		},
+		constants.FieldBootstrapInsecure: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Skip the TLS verification of the Harvester server certificate, e.g. for the self-signed certificate of a new Harvester cluster. Prefer `ca_certificate` to trust a certificate which isn't signed by a system CA",
+		},
+		constants.FieldBootstrapTLSServerName: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Server name used to verify the Harvester server certificate, e.g. when `api_url` uses the VIP address",
+		},
+		constants.FieldBootstrapReadinessPollInterval: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "Seconds between the checks whether the Harvester API is ready before the login, the checks back off up to 10 seconds when it is not set. The total wait is limited by the create timeout",
+		},
+		constants.FieldBootstrapRevokeSessionToken: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Revoke the login session token right after the kubeconfig is generated, instead of when the resource is destroyed. Destroy revokes it with the kubeconfig token, or the `password` or `initial_password` which is still valid",
+		},
+		constants.FieldBootstrapSessionTTL: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "1m",
+			ValidateFunc: util.IsPositiveDuration,
+			Description:  "Time to live of the login session token, e.g. 1m or 1h. The session is only used while the resource is created or the kubeconfig is renewed, with the default 1m it usually expires on its own long before it would be revoked on destroy",
+		},
+		constants.FieldBootstrapSessionDescription: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     bootstrapDefaultSessionDesc,
+			Description: "Description of the login session token",
+		},
+		constants.FieldBootstrapInitialSettings: {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Settings applied before the kubeconfig is generated, e.g. `server-url`, `ui-source` or `backup-target`. The key is the name of the Harvester or Rancher setting. Changed values are applied again, but the settings are not read back",
+		},
 		constants.FieldShouldUpdatePassword: {
 			Type:     schema.TypeBool,
 			Computed: true,
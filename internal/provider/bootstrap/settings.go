@@ -0,0 +1,80 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+)
+
+// settingTypes are the Steve types of the settings, a setting which is not a
+// Harvester setting, e.g. server-url, is a Rancher setting.
+var settingTypes = []string{
+	"harvesterhci.io.settings",
+	"management.cattle.io.settings",
+}
+
+// applySettings sets the value of the settings with the bootstrap session.
+func applySettings(apiURL, token string, tls tlsOptions, settings map[string]interface{}) error {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := applySetting(apiURL, token, tls, name, settings[name].(string)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applySetting(apiURL, token string, tls tlsOptions, name, value string) error {
+	for _, settingType := range settingTypes {
+		settingURL := fmt.Sprintf("%s/v1/%s/%s", apiURL, settingType, name)
+		getResp, err := util.DoGet(settingURL, "", "", token, tls.CACertificate, tls.ServerName, tls.Insecure)
+		if err != nil {
+			return err
+		}
+		if getResp.StatusCode == http.StatusNotFound {
+			getResp.Body.Close()
+			continue
+		}
+		if getResp.StatusCode != http.StatusOK {
+			getResp.Body.Close()
+			return fmt.Errorf("failed to get setting %s, status code %d", name, getResp.StatusCode)
+		}
+
+		setting := map[string]interface{}{}
+		err = json.NewDecoder(getResp.Body).Decode(&setting)
+		getResp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode setting %s: %v", name, err)
+		}
+		setting["value"] = value
+		data, err := json.Marshal(setting)
+		if err != nil {
+			return fmt.Errorf("failed to marshal setting %s: %v", name, err)
+		}
+
+		log.Printf("Doing update setting %s", name)
+		putResp, err := util.DoPut(settingURL, string(data), tls.CACertificate, tls.ServerName, tls.Insecure, map[string]string{
+			"Accept":        "application/json",
+			"Content-Type":  "application/json",
+			"Authorization": fmt.Sprintf("Bearer %s", token),
+		})
+		if err != nil {
+			return err
+		}
+		putResp.Body.Close()
+		if putResp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to update setting %s, status code %d", name, putResp.StatusCode)
+		}
+		return nil
+	}
+	return fmt.Errorf("setting %s is neither a Harvester nor a Rancher setting", name)
+}
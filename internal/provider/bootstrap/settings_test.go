@@ -0,0 +1,125 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSettingsServer serves the Harvester setting ui-source, the Rancher
+// setting server-url and the setting backup-target, which can't be read.
+// Updating a setting fails with putStatus if it isn't 200.
+func testSettingsServer(t *testing.T, putStatus int, updated *[]string) *httptest.Server {
+	settings := map[string]map[string]interface{}{
+		"/v1/harvesterhci.io.settings/ui-source":       {"id": "ui-source", "type": "harvesterhci.io.setting", "default": "auto", "value": ""},
+		"/v1/management.cattle.io.settings/server-url": {"id": "server-url", "type": "management.cattle.io.setting", "value": ""},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-session:secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/backup-target") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		setting, ok := settings[r.URL.Path]
+		switch {
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(setting)
+		case r.Method == http.MethodPut:
+			update := map[string]interface{}{}
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Errorf("failed to decode the update of %s: %v", r.URL.Path, err)
+			}
+			// the update keeps the other fields of the setting
+			expected := map[string]interface{}{}
+			for key, value := range setting {
+				expected[key] = value
+			}
+			expected["value"] = update["value"]
+			if !reflect.DeepEqual(update, expected) {
+				t.Errorf("expected the update %v, got %v", expected, update)
+			}
+			*updated = append(*updated, r.URL.Path+"="+update["value"].(string))
+			w.WriteHeader(putStatus)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func TestApplySettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		settings  map[string]interface{}
+		putStatus int
+		updated   []string
+		wantErr   string
+	}{
+		{
+			name:      "harvester and rancher settings",
+			token:     "token-session:secret",
+			settings:  map[string]interface{}{"ui-source": "bundled", "server-url": "https://harvester.example.com"},
+			putStatus: http.StatusOK,
+			updated: []string{
+				"/v1/management.cattle.io.settings/server-url=https://harvester.example.com",
+				"/v1/harvesterhci.io.settings/ui-source=bundled",
+			},
+		},
+		{
+			name:      "unknown setting",
+			token:     "token-session:secret",
+			settings:  map[string]interface{}{"unknown": "value"},
+			putStatus: http.StatusOK,
+			wantErr:   "neither a Harvester nor a Rancher setting",
+		},
+		{
+			name:      "setting which can't be read",
+			token:     "token-session:secret",
+			settings:  map[string]interface{}{"backup-target": "{}"},
+			putStatus: http.StatusOK,
+			wantErr:   "failed to get setting backup-target, status code 403",
+		},
+		{
+			name:      "rejected update",
+			token:     "token-session:secret",
+			settings:  map[string]interface{}{"ui-source": "invalid"},
+			putStatus: http.StatusUnprocessableEntity,
+			updated:   []string{"/v1/harvesterhci.io.settings/ui-source=invalid"},
+			wantErr:   "failed to update setting ui-source, status code 422",
+		},
+		{
+			name:      "invalid session",
+			token:     "token-session:invalid",
+			settings:  map[string]interface{}{"ui-source": "bundled"},
+			putStatus: http.StatusOK,
+			wantErr:   "failed to get setting ui-source, status code 401",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated []string
+			server := testSettingsServer(t, tt.putStatus, &updated)
+			defer server.Close()
+
+			err := applySettings(server.URL, tt.token, tlsOptions{}, tt.settings)
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected the error %q, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(updated, tt.updated) {
+				t.Errorf("expected the updates %v, got %v", tt.updated, updated)
+			}
+		})
+	}
+}
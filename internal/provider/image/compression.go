@@ -0,0 +1,160 @@
+package image
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// compression is the format a file to upload is compressed with.
+type compression string
+
+const (
+	compressionNone  compression = ""
+	compressionGzip  compression = "gzip"
+	compressionBzip2 compression = "bzip2"
+	compressionXz    compression = "xz"
+	compressionZstd  compression = "zstd"
+)
+
+var compressionFormats = []struct {
+	compression compression
+	magic       []byte
+	extension   string
+}{
+	{compressionGzip, []byte{0x1f, 0x8b}, ".gz"},
+	{compressionBzip2, []byte("BZh"), ".bz2"},
+	{compressionXz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, ".xz"},
+	{compressionZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}, ".zst"},
+}
+
+// detectCompression returns the compression of the file from its magic bytes,
+// or from its extension if the magic bytes are not known.
+func detectCompression(filePath string) (compression, error) {
+	file, err := os.Open(filePath) //nolint:gosec // G304: filePath is user-provided via Terraform configuration
+	if err != nil {
+		return compressionNone, fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer func() { _ = file.Close() }()
+	header := make([]byte, 6)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return compressionNone, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	header = header[:n]
+	for _, format := range compressionFormats {
+		if bytes.HasPrefix(header, format.magic) {
+			return format.compression, nil
+		}
+	}
+	for _, format := range compressionFormats {
+		if strings.HasSuffix(strings.ToLower(filePath), format.extension) {
+			return format.compression, nil
+		}
+	}
+	return compressionNone, nil
+}
+
+// decompressedName returns the base name of the file without the extension
+// of its compression.
+func decompressedName(filePath string, c compression) string {
+	name := filepath.Base(filePath)
+	for _, format := range compressionFormats {
+		if format.compression == c && strings.HasSuffix(strings.ToLower(name), format.extension) {
+			return name[:len(name)-len(format.extension)]
+		}
+	}
+	return name
+}
+
+// openDecompressed opens the file and returns a reader of its decompressed
+// data.
+func openDecompressed(filePath string, c compression) (io.ReadCloser, error) {
+	file, err := os.Open(filePath) //nolint:gosec // G304: filePath is user-provided via Terraform configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	var reader io.ReadCloser
+	switch c {
+	case compressionNone:
+		return file, nil
+	case compressionGzip:
+		var gzipReader *gzip.Reader
+		if gzipReader, err = gzip.NewReader(file); err == nil {
+			reader = gzipReader
+		}
+	case compressionBzip2:
+		reader = io.NopCloser(bzip2.NewReader(file))
+	case compressionZstd:
+		var zstdReader *zstd.Decoder
+		if zstdReader, err = zstd.NewReader(file); err == nil {
+			reader = zstdReader.IOReadCloser()
+		}
+	case compressionXz:
+		var xzReader *xz.Reader
+		if xzReader, err = xz.NewReader(file); err == nil {
+			reader = io.NopCloser(xzReader)
+		}
+	default:
+		err = fmt.Errorf("unsupported compression %s", c)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to decompress file %s: %w", filePath, err)
+	}
+	return &decompressedReader{ReadCloser: reader, file: file}, nil
+}
+
+// decompressedSize returns the size of the decompressed data of the file,
+// the upload endpoint preallocates the image, so the size must be known
+// before the upload starts. The data is decompressed once and discarded, and
+// compared with the SHA-512 checksum if it is set.
+func decompressedSize(ctx context.Context, filePath string, c compression, checksum string) (int64, error) {
+	reader, err := openDecompressed(filePath, c)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	hash := sha512.New()
+	var data io.Reader = newProgressReader(ctx, reader, 0, "decompressing "+filepath.Base(filePath))
+	if checksum != "" {
+		data = io.TeeReader(data, hash)
+	}
+	size, err := io.Copy(io.Discard, data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decompress file %s: %w", filePath, err)
+	}
+	if checksum != "" {
+		if actual := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(actual, strings.TrimSpace(checksum)) {
+			return 0, fmt.Errorf("the SHA-512 checksum %s of the decompressed file %s doesn't match the checksum %s", actual, filePath, checksum)
+		}
+	}
+	return size, nil
+}
+
+// decompressedReader closes the decompressor and the file.
+type decompressedReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r *decompressedReader) Close() error {
+	err := r.ReadCloser.Close()
+	if fileErr := r.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
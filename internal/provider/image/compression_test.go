@@ -0,0 +1,211 @@
+package image
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const testImageData = "image data, image data, image data"
+
+func compressTestData(t *testing.T, c compression) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	var err error
+	switch c {
+	case compressionNone:
+		return []byte(testImageData)
+	case compressionGzip:
+		writer = gzip.NewWriter(&buf)
+	case compressionXz:
+		writer, err = xz.NewWriter(&buf)
+	case compressionZstd:
+		writer, err = zstd.NewWriter(&buf)
+	default:
+		t.Fatalf("unsupported compression %s", c)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = io.WriteString(writer, testImageData); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filePath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestDetectCompression(t *testing.T) {
+	testCases := []struct {
+		name        string
+		fileName    string
+		data        []byte
+		expectation compression
+	}{
+		{
+			name:        "gzip magic",
+			fileName:    "image.img",
+			data:        compressTestData(t, compressionGzip),
+			expectation: compressionGzip,
+		},
+		{
+			name:        "bzip2 magic",
+			fileName:    "image.img",
+			data:        []byte("BZh91AY&SY"),
+			expectation: compressionBzip2,
+		},
+		{
+			name:        "xz magic",
+			fileName:    "image.img",
+			data:        compressTestData(t, compressionXz),
+			expectation: compressionXz,
+		},
+		{
+			name:        "zstd magic",
+			fileName:    "image.img",
+			data:        compressTestData(t, compressionZstd),
+			expectation: compressionZstd,
+		},
+		{
+			name:        "magic before extension",
+			fileName:    "image.img.gz",
+			data:        compressTestData(t, compressionXz),
+			expectation: compressionXz,
+		},
+		{
+			name:        "extension",
+			fileName:    "image.img.XZ",
+			data:        []byte("unknown"),
+			expectation: compressionXz,
+		},
+		{
+			name:        "short file",
+			fileName:    "image.img",
+			data:        []byte{0x1f},
+			expectation: compressionNone,
+		},
+		{
+			name:        "uncompressed",
+			fileName:    "image.qcow2",
+			data:        []byte("QFI\xfb"),
+			expectation: compressionNone,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := detectCompression(writeTestFile(t, tc.fileName, tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expectation {
+				t.Errorf("expected %q, got %q", tc.expectation, actual)
+			}
+		})
+	}
+
+	if _, err := detectCompression(filepath.Join(t.TempDir(), "missing.img")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestDecompressedName(t *testing.T) {
+	testCases := []struct {
+		filePath    string
+		compression compression
+		expectation string
+	}{
+		{"/images/leap.qcow2.xz", compressionXz, "leap.qcow2"},
+		{"/images/leap.qcow2.GZ", compressionGzip, "leap.qcow2"},
+		{"/images/leap.qcow2.zst", compressionZstd, "leap.qcow2"},
+		{"/images/leap.qcow2", compressionGzip, "leap.qcow2"},
+		{"/images/leap.qcow2.gz", compressionNone, "leap.qcow2.gz"},
+	}
+	for _, tc := range testCases {
+		if actual := decompressedName(tc.filePath, tc.compression); actual != tc.expectation {
+			t.Errorf("%s: expected %s, got %s", tc.filePath, tc.expectation, actual)
+		}
+	}
+}
+
+func TestDecompressedSize(t *testing.T) {
+	sum := sha512.Sum512([]byte(testImageData))
+	checksum := hex.EncodeToString(sum[:])
+
+	for _, c := range []compression{compressionGzip, compressionXz, compressionZstd} {
+		t.Run(string(c), func(t *testing.T) {
+			filePath := writeTestFile(t, "image.img", compressTestData(t, c))
+			size, err := decompressedSize(context.Background(), filePath, c, strings.ToUpper(checksum))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != int64(len(testImageData)) {
+				t.Errorf("expected the size %d, got %d", len(testImageData), size)
+			}
+
+			if _, err = decompressedSize(context.Background(), filePath, c, strings.Repeat("0", 128)); err == nil || !strings.Contains(err.Error(), "doesn't match the checksum") {
+				t.Errorf("expected a checksum mismatch, got %v", err)
+			}
+		})
+	}
+
+	filePath := writeTestFile(t, "image.img.xz", []byte("not xz data"))
+	if _, err := decompressedSize(context.Background(), filePath, compressionXz, ""); err == nil {
+		t.Error("expected an error for corrupted data")
+	}
+}
+
+func TestFileUploadSource(t *testing.T) {
+	for _, c := range []compression{compressionNone, compressionGzip, compressionXz} {
+		t.Run(string(c), func(t *testing.T) {
+			fileName := "image.img"
+			for _, format := range compressionFormats {
+				if format.compression == c {
+					fileName += format.extension
+				}
+			}
+			source, err := fileUploadSource(context.Background(), writeTestFile(t, fileName, compressTestData(t, c)), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if source.Name != "image.img" {
+				t.Errorf("expected the name image.img, got %s", source.Name)
+			}
+			// each attempt reads the data again
+			for attempt := 0; attempt < 2; attempt++ {
+				reader, size, err := source.Open(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, err := io.ReadAll(reader)
+				_ = reader.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != testImageData || size != int64(len(testImageData)) {
+					t.Errorf("expected %q of size %d, got %q of size %d", testImageData, len(testImageData), data, size)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package image
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+const (
+	credentialsKeyUsername = "username"
+	credentialsKeyPassword = "password"
+	credentialsKeyToken    = "token"
+)
+
+// imageDownload is the url of a download image and the headers to fetch it.
+type imageDownload struct {
+	URL     string
+	Headers map[string]string
+}
+
+// getImageDownload returns the url and headers of the download of the image,
+// with the download_credentials applied. The url is presigned for S3
+// credentials, and a secret is turned into an Authorization header.
+func getImageDownload(ctx context.Context, c *client.Client, d *schema.ResourceData, namespace string) (*imageDownload, error) {
+	download := &imageDownload{
+		URL:     d.Get(constants.FieldImageURL).(string),
+		Headers: map[string]string{},
+	}
+
+	credentials := d.Get(constants.FieldImageDownloadCredentials).([]interface{})
+	if len(credentials) > 0 && credentials[0] != nil {
+		r := credentials[0].(map[string]interface{})
+		if secretName := r[constants.FieldImageCredentialsSecretName].(string); secretName != "" {
+			secretNamespace := r[constants.FieldImageCredentialsSecretNamespace].(string)
+			if secretNamespace == "" {
+				secretNamespace = namespace
+			}
+			authorization, err := getSecretAuthorization(ctx, c, secretNamespace, secretName)
+			if err != nil {
+				return nil, err
+			}
+			download.Headers["Authorization"] = authorization
+		}
+		if accessKeyID := r[constants.FieldImageCredentialsS3AccessKeyID].(string); accessKeyID != "" {
+			expiry, err := time.ParseDuration(r[constants.FieldImageCredentialsS3PresignExpiry].(string))
+			if err != nil {
+				return nil, err
+			}
+			if download.URL, err = presignS3URL(download.URL, s3Credentials{
+				AccessKeyID:     accessKeyID,
+				SecretAccessKey: r[constants.FieldImageCredentialsS3SecretAccessKey].(string),
+				SessionToken:    r[constants.FieldImageCredentialsS3SessionToken].(string),
+				Region:          r[constants.FieldImageCredentialsS3Region].(string),
+			}, expiry, time.Now()); err != nil {
+				return nil, fmt.Errorf("failed to presign url: %w", err)
+			}
+		}
+	}
+
+	// the headers which are set explicitly take precedence
+	for k, v := range d.Get(constants.FieldImageDownloadHeaders).(map[string]interface{}) {
+		download.Headers[k] = v.(string)
+	}
+	return download, nil
+}
+
+// getSecretAuthorization returns the Authorization header from the token, or
+// the username and password, of the secret.
+func getSecretAuthorization(ctx context.Context, c *client.Client, namespace, name string) (string, error) {
+	secret, err := c.KubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the download credentials secret %s/%s: %w", namespace, name, err)
+	}
+	if token := string(secret.Data[credentialsKeyToken]); token != "" {
+		return "Bearer " + token, nil
+	}
+	username, password := string(secret.Data[credentialsKeyUsername]), string(secret.Data[credentialsKeyPassword])
+	if username == "" || password == "" {
+		return "", fmt.Errorf("the download credentials secret %s/%s must have the key %q, or the keys %q and %q",
+			namespace, name, credentialsKeyToken, credentialsKeyUsername, credentialsKeyPassword)
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+}
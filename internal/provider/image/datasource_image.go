@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 
+	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
 	"github.com/harvester/terraform-provider-harvester/pkg/constants"
 )
 
@@ -33,7 +35,7 @@ func dataSourceImageRead(ctx context.Context, d *schema.ResourceData, meta inter
 		if err != nil {
 			return diag.FromErr(err)
 		}
-		return diag.FromErr(resourceImageImport(d, image))
+		return dataSourceImageImport(ctx, c, d, image)
 	}
 
 	if displayName != "" {
@@ -43,7 +45,7 @@ func dataSourceImageRead(ctx context.Context, d *schema.ResourceData, meta inter
 		}
 		for i, image := range images.Items {
 			if image.Spec.DisplayName == displayName {
-				return diag.FromErr(resourceImageImport(d, &images.Items[i]))
+				return dataSourceImageImport(ctx, c, d, &images.Items[i])
 			}
 		}
 		return diag.FromErr(fmt.Errorf("can not find image %s in namespace %s", displayName, namespace))
@@ -51,3 +53,10 @@ func dataSourceImageRead(ctx context.Context, d *schema.ResourceData, meta inter
 
 	return diag.FromErr(fmt.Errorf("must specify image %s or %s", constants.FieldCommonName, constants.FieldImageDisplayName))
 }
+
+func dataSourceImageImport(ctx context.Context, c *client.Client, d *schema.ResourceData, image *harvsterv1.VirtualMachineImage) diag.Diagnostics {
+	if err := resourceImageImport(d, image); err != nil {
+		return diag.FromErr(err)
+	}
+	return setImageUsedBy(ctx, c, d, image)
+}
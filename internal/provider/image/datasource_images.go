@@ -0,0 +1,159 @@
+package image
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/builder"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/importer"
+)
+
+func DataSourceImages() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceImagesRead,
+		Schema:      ImagesDataSourceSchema(),
+	}
+}
+
+func dataSourceImagesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace := d.Get(constants.FieldCommonNamespace).(string)
+
+	images, err := c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: imagesLabelSelector(d.Get(constants.FieldCommonLabels).(map[string]interface{}), d.Get(constants.FieldCommonTags).(map[string]interface{})),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	filter := imagesFilter{
+		osType:     d.Get(constants.FieldImagesOSType).(string),
+		sourceType: d.Get(constants.FieldImageSourceType).(string),
+		state:      d.Get(constants.FieldCommonState).(string),
+	}
+	if v := d.Get(constants.FieldImagesDisplayNameRegex).(string); v != "" {
+		if filter.displayNameRegex, err = regexp.Compile(v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	var matches []map[string]interface{}
+	for i := range images.Items {
+		image := &images.Items[i]
+		stateGetter, err := importer.ResourceImageStateGetter(image)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		states := stateGetter.States
+		// the source type of an image downloaded by the provider is read from its annotation
+		imageSourceType := string(states[constants.FieldImageSourceType].(harvsterv1.VirtualMachineImageSourceType))
+		if !filter.match(image, imageSourceType, states[constants.FieldCommonState].(string)) {
+			continue
+		}
+		matches = append(matches, map[string]interface{}{
+			"id":                                 stateGetter.ID,
+			constants.FieldCommonName:            image.Name,
+			constants.FieldCommonNamespace:       image.Namespace,
+			constants.FieldCommonDescription:     states[constants.FieldCommonDescription],
+			constants.FieldImageDisplayName:      image.Spec.DisplayName,
+			constants.FieldImageSourceType:       imageSourceType,
+			constants.FieldImageURL:              states[constants.FieldImageURL],
+			constants.FieldImageSize:             int(image.Status.Size),
+			constants.FieldImageStorageClassName: states[constants.FieldImageStorageClassName],
+			constants.FieldImagesOSType:          imageOSType(image),
+			constants.FieldImagesCreationTime:    image.CreationTimestamp.UTC().Format(time.RFC3339),
+			constants.FieldCommonState:           states[constants.FieldCommonState],
+			constants.FieldCommonLabels:          states[constants.FieldCommonLabels],
+			constants.FieldCommonTags:            states[constants.FieldCommonTags],
+		})
+	}
+
+	sortImages(matches)
+	if d.Get(constants.FieldImagesMostRecent).(bool) && len(matches) > 1 {
+		matches = matches[:1]
+	}
+
+	ids := make([]string, 0, len(matches))
+	list := make([]interface{}, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match["id"].(string))
+		list = append(list, match)
+	}
+	hash := sha256.Sum256([]byte(namespace + "/" + strings.Join(ids, ",")))
+	d.SetId(hex.EncodeToString(hash[:]))
+	if err = d.Set(constants.FieldImagesIDs, ids); err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(d.Set(constants.FieldImagesImages, list))
+}
+
+// imagesLabelSelector returns the selector of the labels and tags, they are
+// matched by the API server.
+func imagesLabelSelector(imageLabels, tags map[string]interface{}) string {
+	selector := labels.Set{}
+	for k, v := range imageLabels {
+		selector[k] = v.(string)
+	}
+	for k, v := range tags {
+		selector[builder.LabelPrefixHarvesterTag+k] = v.(string)
+	}
+	return selector.AsSelector().String()
+}
+
+// imagesFilter matches the images by the filters of the data source which
+// the API server can't match, an empty filter matches all images.
+type imagesFilter struct {
+	displayNameRegex *regexp.Regexp
+	osType           string
+	sourceType       string
+	state            string
+}
+
+func (f *imagesFilter) match(image *harvsterv1.VirtualMachineImage, sourceType, state string) bool {
+	if f.displayNameRegex != nil && !f.displayNameRegex.MatchString(image.Spec.DisplayName) {
+		return false
+	}
+	if f.osType != "" && imageOSType(image) != f.osType {
+		return false
+	}
+	if f.sourceType != "" && sourceType != f.sourceType {
+		return false
+	}
+	return f.state == "" || state == f.state
+}
+
+// sortImages sorts the most recently created images first, by ID if they are
+// created at the same time.
+func sortImages(images []map[string]interface{}) {
+	sort.SliceStable(images, func(i, j int) bool {
+		ti, tj := images[i][constants.FieldImagesCreationTime].(string), images[j][constants.FieldImagesCreationTime].(string)
+		if ti != tj {
+			return ti > tj
+		}
+		return images[i]["id"].(string) < images[j]["id"].(string)
+	})
+}
+
+// imageOSType returns the OS type label of the image, or its annotation.
+func imageOSType(image *harvsterv1.VirtualMachineImage) string {
+	if osType := image.Labels[constants.LabelImageOSType]; osType != "" {
+		return osType
+	}
+	return image.Annotations[constants.LabelImageOSType]
+}
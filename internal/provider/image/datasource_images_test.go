@@ -0,0 +1,130 @@
+package image
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/builder"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func TestImagesLabelSelector(t *testing.T) {
+	testCases := []struct {
+		name        string
+		labels      map[string]interface{}
+		tags        map[string]interface{}
+		expectation string
+	}{
+		{
+			name:        "no filter",
+			expectation: "",
+		},
+		{
+			name:        "labels and tags",
+			labels:      map[string]interface{}{"team": "infra"},
+			tags:        map[string]interface{}{"os": "leap"},
+			expectation: builder.LabelPrefixHarvesterTag + "os=leap,team=infra",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := imagesLabelSelector(tc.labels, tc.tags); actual != tc.expectation {
+				t.Errorf("expected %q, got %q", tc.expectation, actual)
+			}
+		})
+	}
+}
+
+func TestImagesFilterMatch(t *testing.T) {
+	image := &harvsterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{constants.LabelImageOSType: "linux"},
+		},
+		Spec: harvsterv1.VirtualMachineImageSpec{
+			DisplayName: "openSUSE-Leap-15.6",
+		},
+	}
+	testCases := []struct {
+		name        string
+		filter      imagesFilter
+		expectation bool
+	}{
+		{
+			name:        "empty filter",
+			expectation: true,
+		},
+		{
+			name: "all filters",
+			filter: imagesFilter{
+				displayNameRegex: regexp.MustCompile("^openSUSE-Leap-"),
+				osType:           "linux",
+				sourceType:       "download",
+				state:            "Active",
+			},
+			expectation: true,
+		},
+		{
+			name:        "display name",
+			filter:      imagesFilter{displayNameRegex: regexp.MustCompile("^ubuntu-")},
+			expectation: false,
+		},
+		{
+			name:        "os type",
+			filter:      imagesFilter{osType: "windows"},
+			expectation: false,
+		},
+		{
+			name:        "source type",
+			filter:      imagesFilter{sourceType: "upload"},
+			expectation: false,
+		},
+		{
+			name:        "state",
+			filter:      imagesFilter{state: "Failed"},
+			expectation: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.filter.match(image, "download", "Active"); actual != tc.expectation {
+				t.Errorf("expected %t, got %t", tc.expectation, actual)
+			}
+		})
+	}
+}
+
+func TestImageOSType(t *testing.T) {
+	image := &harvsterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{constants.LabelImageOSType: "windows"},
+			Annotations: map[string]string{constants.LabelImageOSType: "linux"},
+		},
+	}
+	if actual := imageOSType(image); actual != "windows" {
+		t.Errorf("expected the label windows, got %s", actual)
+	}
+	delete(image.Labels, constants.LabelImageOSType)
+	if actual := imageOSType(image); actual != "linux" {
+		t.Errorf("expected the annotation linux, got %s", actual)
+	}
+}
+
+func TestSortImages(t *testing.T) {
+	images := []map[string]interface{}{
+		{"id": "default/b", constants.FieldImagesCreationTime: "2026-01-01T00:00:00Z"},
+		{"id": "default/c", constants.FieldImagesCreationTime: "2026-02-01T00:00:00Z"},
+		{"id": "default/a", constants.FieldImagesCreationTime: "2026-01-01T00:00:00Z"},
+	}
+	sortImages(images)
+	var ids []string
+	for _, image := range images {
+		ids = append(ids, image["id"].(string))
+	}
+	if expectation := []string{"default/c", "default/a", "default/b"}; !reflect.DeepEqual(ids, expectation) {
+		t.Errorf("expected %v, got %v", expectation, ids)
+	}
+}
@@ -0,0 +1,281 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	harvsterutil "github.com/harvester/harvester/pkg/util"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+)
+
+const (
+	encryptionOperationEncrypt = "encrypt"
+	encryptionOperationDecrypt = "decrypt"
+
+	encryptionSecretKeyValue    = "CRYPTO_KEY_VALUE"
+	encryptionSecretKeyCipher   = "CRYPTO_KEY_CIPHER"
+	encryptionSecretKeyHash     = "CRYPTO_KEY_HASH"
+	encryptionSecretKeyProvider = "CRYPTO_KEY_PROVIDER"
+	encryptionSecretKeySize     = "CRYPTO_KEY_SIZE"
+	encryptionSecretKeyPBKDF    = "CRYPTO_PBKDF"
+
+	longhornProvisioner = "driver.longhorn.io"
+)
+
+// imageEncryption is kept in the annotation AnnotationImageEncryption, with
+// the secret and storage class which are deleted with the image, unless
+// volumes still use them.
+type imageEncryption struct {
+	Secret       string `json:"secret,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// prepareImageEncryption validates the source image of the encryption block,
+// creates the secret and the encrypted storage class of an encryption, and
+// sets up the image to clone the source image. The returned cleanup deletes
+// what was created, if the image can't be created.
+func prepareImageEncryption(ctx context.Context, c *client.Client, d *schema.ResourceData, image *harvsterv1.VirtualMachineImage) (func(), error) {
+	r := d.Get(constants.FieldImageEncryption).([]interface{})[0].(map[string]interface{})
+	operation := r[constants.FieldImageEncryptionOperation].(string)
+	sourceNamespace, sourceName, err := helper.NamespacedNamePartsByDefault(r[constants.FieldImageEncryptionSourceImage].(string), image.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the source image %s/%s: %w", sourceNamespace, sourceName, err)
+	}
+	sourceEncrypted := source.Spec.SecurityParameters != nil && string(source.Spec.SecurityParameters.CryptoOperation) == encryptionOperationEncrypt
+	switch {
+	case operation == encryptionOperationEncrypt && sourceEncrypted:
+		return nil, fmt.Errorf("the source image %s/%s is already encrypted", sourceNamespace, sourceName)
+	case operation == encryptionOperationDecrypt && !sourceEncrypted:
+		return nil, fmt.Errorf("the source image %s/%s is not encrypted", sourceNamespace, sourceName)
+	}
+	for _, condition := range source.Status.Conditions {
+		if condition.Type == harvsterv1.ImageImported && condition.Status != corev1.ConditionTrue {
+			return nil, fmt.Errorf("the source image %s/%s is not ready", sourceNamespace, sourceName)
+		}
+	}
+
+	image.Spec.SourceType = harvsterv1.VirtualMachineImageSourceTypeClone
+	image.Spec.SecurityParameters = &harvsterv1.VirtualMachineImageSecurityParameters{
+		CryptoOperation:      harvsterv1.VirtualMachineImageCryptoOperationType(operation),
+		SourceImageName:      sourceName,
+		SourceImageNamespace: sourceNamespace,
+	}
+	encryption := imageEncryption{}
+	cleanup := func() {
+		cleanupCtx, cancel := util.CleanupContext(ctx)
+		defer cancel()
+		for _, warning := range deleteImageEncryption(cleanupCtx, c, encryption) {
+			tflog.Warn(ctx, warning.Summary, map[string]interface{}{"detail": warning.Detail})
+		}
+	}
+
+	if operation == encryptionOperationEncrypt {
+		secretNamespace, secretName := r[constants.FieldImageEncryptionSecretNamespace].(string), r[constants.FieldImageEncryptionSecretName].(string)
+		if secretNamespace == "" {
+			secretNamespace = image.Namespace
+		}
+		if passphrase := r[constants.FieldImageEncryptionPassphrase].(string); passphrase != "" {
+			secretName = image.Name + "-encryption"
+			secret := &corev1.Secret{
+				ObjectMeta: util.NewObjectMeta(secretNamespace, secretName),
+				Type:       corev1.SecretTypeOpaque,
+				StringData: map[string]string{
+					encryptionSecretKeyValue:    passphrase,
+					encryptionSecretKeyCipher:   r[constants.FieldImageEncryptionCipher].(string),
+					encryptionSecretKeyHash:     r[constants.FieldImageEncryptionHash].(string),
+					encryptionSecretKeyProvider: "secret",
+					encryptionSecretKeySize:     strconv.Itoa(r[constants.FieldImageEncryptionKeySize].(int)),
+					encryptionSecretKeyPBKDF:    r[constants.FieldImageEncryptionPBKDF].(string),
+				},
+			}
+			if _, err = c.KubeClient.CoreV1().Secrets(secretNamespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+				return nil, fmt.Errorf("failed to create the encryption secret %s/%s: %w", secretNamespace, secretName, err)
+			}
+			encryption.Secret = helper.BuildNamespacedName(secretNamespace, secretName)
+		} else if secretName == "" {
+			return nil, fmt.Errorf("%s or %s must be set to encrypt an image", constants.FieldImageEncryptionPassphrase, constants.FieldImageEncryptionSecretName)
+		} else if err = validateEncryptionSecret(ctx, c, secretNamespace, secretName); err != nil {
+			return nil, err
+		}
+
+		storageClass := newEncryptedStorageClass(image, secretNamespace, secretName, r[constants.FieldImageEncryptionNumberOfReplicas].(int))
+		if _, err = c.StorageClassClient.StorageClasses().Create(ctx, storageClass, metav1.CreateOptions{}); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to create the encrypted storage class %s: %w", storageClass.Name, err)
+		}
+		encryption.StorageClass = storageClass.Name
+		image.Annotations[harvsterutil.AnnotationStorageClassName] = storageClass.Name
+	}
+
+	annotation, err := json.Marshal(encryption)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	image.Annotations[constants.AnnotationImageEncryption] = string(annotation)
+	return cleanup, nil
+}
+
+// validateEncryptionSecret checks that the existing secret has the keys of a
+// Longhorn encryption secret.
+func validateEncryptionSecret(ctx context.Context, c *client.Client, namespace, name string) error {
+	secret, err := c.KubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the encryption secret %s/%s: %w", namespace, name, err)
+	}
+	for _, key := range []string{encryptionSecretKeyValue, encryptionSecretKeyProvider} {
+		if len(secret.Data[key]) == 0 {
+			return fmt.Errorf("the encryption secret %s/%s has no key %s", namespace, name, key)
+		}
+	}
+	return nil
+}
+
+// newEncryptedStorageClass returns a Longhorn storage class which encrypts
+// its volumes with the secret.
+func newEncryptedStorageClass(image *harvsterv1.VirtualMachineImage, secretNamespace, secretName string, numberOfReplicas int) *storagev1.StorageClass {
+	reclaimPolicy := corev1.PersistentVolumeReclaimDelete
+	allowVolumeExpansion := true
+	volumeBindingMode := storagev1.VolumeBindingImmediate
+	parameters := map[string]string{
+		"migratable":          "true",
+		"numberOfReplicas":    strconv.Itoa(numberOfReplicas),
+		"staleReplicaTimeout": "30",
+		"encrypted":           "true",
+	}
+	for _, prefix := range []string{"node-publish", "node-stage", "provisioner"} {
+		parameters["csi.storage.k8s.io/"+prefix+"-secret-name"] = secretName
+		parameters["csi.storage.k8s.io/"+prefix+"-secret-namespace"] = secretNamespace
+	}
+	return &storagev1.StorageClass{
+		ObjectMeta:           util.NewObjectMeta("", image.Namespace+"-"+image.Name+"-encrypted"),
+		Provisioner:          longhornProvisioner,
+		ReclaimPolicy:        &reclaimPolicy,
+		AllowVolumeExpansion: &allowVolumeExpansion,
+		VolumeBindingMode:    &volumeBindingMode,
+		Parameters:           parameters,
+	}
+}
+
+// deleteImageEncryption deletes the storage class and the secret which are
+// created for the encryption of an image. They are kept with a warning while
+// volumes use them, since the volumes of an encrypted image need the secret
+// to be attached.
+func deleteImageEncryption(ctx context.Context, c *client.Client, encryption imageEncryption) diag.Diagnostics {
+	if encryption.StorageClass == "" && encryption.Secret == "" {
+		return nil
+	}
+	inUse, err := imageEncryptionInUse(ctx, c, encryption)
+	if err != nil || inUse {
+		detail := "Volumes still use them."
+		if err != nil {
+			detail = fmt.Sprintf("Failed to check if volumes still use them: %s.", err)
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("kept %s of the image encryption", encryption),
+			Detail:   detail + " Delete them when no volume uses them anymore.",
+		}}
+	}
+
+	if encryption.StorageClass != "" {
+		if err := c.StorageClassClient.StorageClasses().Delete(ctx, encryption.StorageClass, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			tflog.Warn(ctx, "failed to delete the encrypted storage class", map[string]interface{}{"storage_class": encryption.StorageClass, "error": err.Error()})
+		}
+	}
+	if encryption.Secret != "" {
+		namespace, name, err := helper.NamespacedNameParts(encryption.Secret)
+		if err == nil {
+			err = c.KubeClient.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			tflog.Warn(ctx, "failed to delete the encryption secret", map[string]interface{}{"secret": encryption.Secret, "error": err.Error()})
+		}
+	}
+	return nil
+}
+
+func (e imageEncryption) String() string {
+	var parts []string
+	if e.StorageClass != "" {
+		parts = append(parts, "the storage class "+e.StorageClass)
+	}
+	if e.Secret != "" {
+		parts = append(parts, "the secret "+e.Secret)
+	}
+	return strings.Join(parts, " and ")
+}
+
+// imageEncryptionInUse lists the volumes in all namespaces and reports whether
+// any of them uses the storage class or the secret of the encryption.
+func imageEncryptionInUse(ctx context.Context, c *client.Client, encryption imageEncryption) (bool, error) {
+	pvs, err := c.KubeClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+	pvcs, err := c.KubeClient.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	return usesImageEncryption(encryption, pvs.Items, pvcs.Items), nil
+}
+
+// usesImageEncryption reports whether a persistent volume references the
+// secret, which the CSI driver needs to attach it, or whether a volume is of
+// the encrypted storage class.
+func usesImageEncryption(encryption imageEncryption, pvs []corev1.PersistentVolume, pvcs []corev1.PersistentVolumeClaim) bool {
+	for _, pv := range pvs {
+		if encryption.StorageClass != "" && pv.Spec.StorageClassName == encryption.StorageClass {
+			return true
+		}
+		if encryption.Secret == "" || pv.Spec.CSI == nil {
+			continue
+		}
+		csi := pv.Spec.CSI
+		for _, ref := range []*corev1.SecretReference{csi.NodePublishSecretRef, csi.NodeStageSecretRef, csi.ControllerPublishSecretRef, csi.ControllerExpandSecretRef, csi.NodeExpandSecretRef} {
+			if ref != nil && helper.BuildNamespacedName(ref.Namespace, ref.Name) == encryption.Secret {
+				return true
+			}
+		}
+	}
+	for _, pvc := range pvcs {
+		if encryption.StorageClass != "" && pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == encryption.StorageClass {
+			return true
+		}
+	}
+	return false
+}
+
+// getImageEncryption returns what was created for the encryption of the
+// image, if it is encrypted with the encryption block.
+func getImageEncryption(image *harvsterv1.VirtualMachineImage) (imageEncryption, bool) {
+	var encryption imageEncryption
+	annotation, ok := image.Annotations[constants.AnnotationImageEncryption]
+	if !ok {
+		return encryption, false
+	}
+	if err := json.Unmarshal([]byte(annotation), &encryption); err != nil {
+		return imageEncryption{}, true
+	}
+	return encryption, true
+}
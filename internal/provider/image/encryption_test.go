@@ -0,0 +1,100 @@
+package image
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestUsesImageEncryption(t *testing.T) {
+	encryption := imageEncryption{
+		Secret:       "default/leap-encryption",
+		StorageClass: "default-leap-encrypted",
+	}
+	storageClassName := encryption.StorageClass
+	csiVolume := func(ref *corev1.SecretReference) corev1.PersistentVolume {
+		return corev1.PersistentVolume{
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{NodePublishSecretRef: ref},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		encryption  imageEncryption
+		pvs         []corev1.PersistentVolume
+		pvcs        []corev1.PersistentVolumeClaim
+		expectation bool
+	}{
+		{
+			name:        "no volumes",
+			encryption:  encryption,
+			expectation: false,
+		},
+		{
+			name:       "persistent volume references the secret",
+			encryption: encryption,
+			pvs: []corev1.PersistentVolume{
+				csiVolume(&corev1.SecretReference{Namespace: "default", Name: "other"}),
+				csiVolume(&corev1.SecretReference{Namespace: "default", Name: "leap-encryption"}),
+			},
+			expectation: true,
+		},
+		{
+			name:       "persistent volume of the storage class",
+			encryption: encryption,
+			pvs: []corev1.PersistentVolume{
+				{Spec: corev1.PersistentVolumeSpec{StorageClassName: storageClassName}},
+			},
+			expectation: true,
+		},
+		{
+			name:       "pending volume of the storage class",
+			encryption: encryption,
+			pvcs: []corev1.PersistentVolumeClaim{
+				{Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClassName}},
+			},
+			expectation: true,
+		},
+		{
+			name:       "secret which isn't created by the encryption",
+			encryption: imageEncryption{StorageClass: encryption.StorageClass},
+			pvs: []corev1.PersistentVolume{
+				csiVolume(&corev1.SecretReference{Namespace: "default", Name: "leap-encryption"}),
+			},
+			expectation: false,
+		},
+		{
+			name:       "other volumes",
+			encryption: encryption,
+			pvs: []corev1.PersistentVolume{
+				csiVolume(nil),
+				{Spec: corev1.PersistentVolumeSpec{StorageClassName: "longhorn"}},
+			},
+			pvcs: []corev1.PersistentVolumeClaim{
+				{},
+			},
+			expectation: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := usesImageEncryption(tc.encryption, tc.pvs, tc.pvcs); actual != tc.expectation {
+				t.Errorf("expected %t, got %t", tc.expectation, actual)
+			}
+		})
+	}
+}
+
+func TestImageEncryptionString(t *testing.T) {
+	encryption := imageEncryption{Secret: "default/leap-encryption", StorageClass: "default-leap-encrypted"}
+	if expectation := "the storage class default-leap-encrypted and the secret default/leap-encryption"; encryption.String() != expectation {
+		t.Errorf("expected %q, got %q", expectation, encryption.String())
+	}
+	if expectation := "the storage class default-leap-encrypted"; (imageEncryption{StorageClass: "default-leap-encrypted"}).String() != expectation {
+		t.Errorf("expected %q, got %q", expectation, imageEncryption{StorageClass: "default-leap-encrypted"}.String())
+	}
+}
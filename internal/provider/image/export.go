@@ -0,0 +1,91 @@
+package image
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+)
+
+// snapshotVolumeForExport takes a snapshot of the volume and restores it to a
+// new volume, which is exported instead of the volume of a running virtual
+// machine. The returned cleanup deletes the snapshot and the new volume which
+// are created here, checked by their UID.
+func snapshotVolumeForExport(ctx context.Context, c *client.Client, namespace, pvcName, imageName string) (string, func(), error) {
+	pvc, err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get the volume %s/%s to export: %w", namespace, pvcName, err)
+	}
+	var storageClassName string
+	if pvc.Spec.StorageClassName != nil {
+		storageClassName = *pvc.Spec.StorageClassName
+	}
+	className, err := util.VolumeSnapshotClassName(ctx, c, storageClassName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get the volume snapshot class of the volume %s/%s: %w", namespace, pvcName, err)
+	}
+
+	// the names are generated, so concurrent exports and existing volumes
+	// don't collide, and only the objects created here are deleted
+	generateName := imageName + "-export-"
+	var snapshotName, restoredName string
+	var snapshotUID, restoredUID types.UID
+	cleanup := func() {
+		cleanupCtx, cancel := util.CleanupContext(ctx)
+		defer cancel()
+		if restoredUID != "" {
+			err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Delete(cleanupCtx, restoredName, metav1.DeleteOptions{
+				Preconditions: metav1.NewUIDPreconditions(string(restoredUID)),
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				tflog.Warn(ctx, "failed to delete the volume of the export", map[string]interface{}{"volume": namespace + "/" + restoredName, "error": err.Error()})
+			}
+		}
+		if snapshotUID != "" {
+			err := c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Delete(cleanupCtx, snapshotName, metav1.DeleteOptions{
+				Preconditions: metav1.NewUIDPreconditions(string(snapshotUID)),
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				tflog.Warn(ctx, "failed to delete the volume snapshot of the export", map[string]interface{}{"volume_snapshot": namespace + "/" + snapshotName, "error": err.Error()})
+			}
+		}
+	}
+
+	tflog.Info(ctx, "taking a snapshot of the volume to export", map[string]interface{}{"volume": namespace + "/" + pvcName})
+	snapshot := util.NewVolumeSnapshot(namespace, "", pvcName, className)
+	snapshot.SetGenerateName(generateName)
+	created, err := c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Create(ctx, snapshot, metav1.CreateOptions{})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to take a snapshot of the volume %s/%s: %w", namespace, pvcName, err)
+	}
+	snapshotName, snapshotUID = created.GetName(), created.GetUID()
+	if err = util.WaitForVolumeSnapshotReady(ctx, c, namespace, snapshotName); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	restored := &corev1.PersistentVolumeClaim{
+		ObjectMeta: util.NewObjectMeta(namespace, ""),
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			VolumeMode:       pvc.Spec.VolumeMode,
+			StorageClassName: pvc.Spec.StorageClassName,
+			Resources:        pvc.Spec.Resources,
+			DataSource:       util.NewVolumeSnapshotDataSource(snapshotName),
+		},
+	}
+	restored.GenerateName = generateName
+	if restored, err = c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, restored, metav1.CreateOptions{}); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to restore the snapshot of the volume %s/%s: %w", namespace, pvcName, err)
+	}
+	restoredName, restoredUID = restored.Name, restored.UID
+	return restoredName, cleanup, nil
+}
@@ -0,0 +1,179 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+)
+
+// testExportServer is the API of the export of the volume default/vm-disk.
+// The snapshot fails with snapshotError if it is set, and the restored volume
+// is rejected with restoreStatus if it isn't 201. The deletes are recorded as
+// the path and the UID of their precondition.
+type testExportServer struct {
+	t             *testing.T
+	snapshotError string
+	restoreStatus int
+
+	created []string
+	deleted []string
+}
+
+func (s *testExportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const (
+		volumes   = "/api/v1/namespaces/default/persistentvolumeclaims"
+		snapshots = "/apis/snapshot.storage.k8s.io/v1/namespaces/default/volumesnapshots"
+	)
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == volumes+"/vm-disk":
+		s.write(w, http.StatusOK, &corev1.PersistentVolumeClaim{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm-disk"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			},
+		})
+	case r.Method == http.MethodPost && r.URL.Path == snapshots:
+		snapshot := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&snapshot)
+		metadata := snapshot["metadata"].(map[string]interface{})
+		s.created = append(s.created, "volumesnapshots/"+metadata["generateName"].(string))
+		metadata["name"] = metadata["generateName"].(string) + "abcde"
+		metadata["uid"] = "snapshot-uid"
+		s.write(w, http.StatusCreated, snapshot)
+	case r.Method == http.MethodGet && r.URL.Path == snapshots+"/leap-export-abcde":
+		status := map[string]interface{}{"readyToUse": true}
+		if s.snapshotError != "" {
+			status = map[string]interface{}{"readyToUse": false, "error": map[string]interface{}{"message": s.snapshotError}}
+		}
+		s.write(w, http.StatusOK, map[string]interface{}{
+			"apiVersion": "snapshot.storage.k8s.io/v1",
+			"kind":       "VolumeSnapshot",
+			"metadata":   map[string]interface{}{"namespace": "default", "name": "leap-export-abcde", "uid": "snapshot-uid"},
+			"status":     status,
+		})
+	case r.Method == http.MethodPost && r.URL.Path == volumes:
+		pvc := &corev1.PersistentVolumeClaim{}
+		_ = json.NewDecoder(r.Body).Decode(pvc)
+		s.created = append(s.created, "persistentvolumeclaims/"+pvc.GenerateName)
+		if pvc.Spec.DataSource == nil || pvc.Spec.DataSource.Name != "leap-export-abcde" {
+			s.t.Errorf("expected the volume to be restored from leap-export-abcde, got %v", pvc.Spec.DataSource)
+		}
+		if s.restoreStatus != http.StatusCreated {
+			s.write(w, s.restoreStatus, &metav1.Status{Status: metav1.StatusFailure, Code: int32(s.restoreStatus), Reason: metav1.StatusReasonForbidden})
+			return
+		}
+		pvc.Name = pvc.GenerateName + "fghij"
+		pvc.UID = "volume-uid"
+		s.write(w, http.StatusCreated, pvc)
+	case r.Method == http.MethodDelete:
+		options := &metav1.DeleteOptions{}
+		_ = json.NewDecoder(r.Body).Decode(options)
+		uid := ""
+		if options.Preconditions != nil && options.Preconditions.UID != nil {
+			uid = string(*options.Preconditions.UID)
+		}
+		parts := strings.Split(r.URL.Path, "/")
+		s.deleted = append(s.deleted, strings.Join(parts[len(parts)-2:], "/")+"@"+uid)
+		s.write(w, http.StatusOK, &metav1.Status{Status: metav1.StatusSuccess})
+	default:
+		s.write(w, http.StatusNotFound, &metav1.Status{Status: metav1.StatusFailure, Code: http.StatusNotFound, Reason: metav1.StatusReasonNotFound})
+	}
+}
+
+func (s *testExportServer) write(w http.ResponseWriter, statusCode int, obj interface{}) {
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		s.t.Error(err)
+	}
+}
+
+func TestSnapshotVolumeForExport(t *testing.T) {
+	testCases := []struct {
+		name          string
+		pvcName       string
+		snapshotError string
+		restoreStatus int
+		expectation   string
+		expectError   string
+		created       []string
+		deleted       []string
+	}{
+		{
+			name:          "exported",
+			pvcName:       "vm-disk",
+			restoreStatus: http.StatusCreated,
+			expectation:   "leap-export-fghij",
+			created:       []string{"volumesnapshots/leap-export-", "persistentvolumeclaims/leap-export-"},
+			// the cleanup deletes only the objects created for the export
+			deleted: []string{"persistentvolumeclaims/leap-export-fghij@volume-uid", "volumesnapshots/leap-export-abcde@snapshot-uid"},
+		},
+		{
+			name:        "missing volume",
+			pvcName:     "missing",
+			expectError: "failed to get the volume default/missing to export",
+		},
+		{
+			name:          "failed snapshot",
+			pvcName:       "vm-disk",
+			snapshotError: "no space left",
+			expectError:   "no space left",
+			created:       []string{"volumesnapshots/leap-export-"},
+			deleted:       []string{"volumesnapshots/leap-export-abcde@snapshot-uid"},
+		},
+		{
+			name:          "rejected volume",
+			pvcName:       "vm-disk",
+			restoreStatus: http.StatusForbidden,
+			expectError:   "failed to restore the snapshot of the volume default/vm-disk",
+			created:       []string{"volumesnapshots/leap-export-", "persistentvolumeclaims/leap-export-"},
+			deleted:       []string{"volumesnapshots/leap-export-abcde@snapshot-uid"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &testExportServer{t: t, snapshotError: tc.snapshotError, restoreStatus: tc.restoreStatus}
+			server := httptest.NewServer(api)
+			defer server.Close()
+			c, err := client.NewClientForConfig(&rest.Config{Host: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pvcName, cleanup, err := snapshotVolumeForExport(context.Background(), c, "default", tc.pvcName, "leap")
+			if tc.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+					t.Fatalf("expected an error containing %q, got %v", tc.expectError, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pvcName != tc.expectation {
+					t.Errorf("expected the volume %s, got %s", tc.expectation, pvcName)
+				}
+				if len(api.deleted) != 0 {
+					t.Errorf("expected nothing to be deleted before the cleanup, got %v", api.deleted)
+				}
+				cleanup()
+			}
+			if !reflect.DeepEqual(api.created, tc.created) {
+				t.Errorf("expected the created objects %v, got %v", tc.created, api.created)
+			}
+			if !reflect.DeepEqual(api.deleted, tc.deleted) {
+				t.Errorf("expected the deleted objects %v, got %v", tc.deleted, api.deleted)
+			}
+		})
+	}
+}
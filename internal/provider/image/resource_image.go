@@ -4,22 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
 	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/rest"
 
 	"github.com/harvester/terraform-provider-harvester/internal/config"
 	"github.com/harvester/terraform-provider-harvester/internal/util"
@@ -35,6 +29,7 @@ func ResourceImage() *schema.Resource {
 		ReadContext:   resourceImageRead,
 		DeleteContext: resourceImageDelete,
 		UpdateContext: resourceImageUpdate,
+		CustomizeDiff: resourceImageCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -49,6 +44,68 @@ func ResourceImage() *schema.Resource {
 	}
 }
 
+// resourceImageCustomizeDiff rejects the features which are not supported by
+// the Harvester version at plan time.
+func resourceImageCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	c := meta.(*config.Config)
+	if d.HasChange(constants.FieldImageBackend) && d.Get(constants.FieldImageBackend).(string) == string(harvsterv1.VMIBackendCDI) {
+		if err := c.CheckCapability(config.CapabilityCDIImageBackend); err != nil {
+			return err
+		}
+	}
+	sourceType := d.Get(constants.FieldImageSourceType).(string)
+	viaProvider := d.Get(constants.FieldImageDownloadViaProvider).(bool)
+	if sourceType == string(harvsterv1.VirtualMachineImageSourceTypeExportVolume) {
+		for _, key := range []string{constants.FieldImagePVCNamespace, constants.FieldImagePVCName} {
+			if d.NewValueKnown(key) && d.Get(key).(string) == "" {
+				return fmt.Errorf("%s must be set when %s is %q", key, constants.FieldImageSourceType, sourceType)
+			}
+		}
+	} else {
+		for _, key := range []string{constants.FieldImagePVCNamespace, constants.FieldImagePVCName, constants.FieldImageExportSnapshot} {
+			if value := d.GetRawConfig().GetAttr(key); value.IsKnown() && !value.IsNull() && !value.Equals(cty.False).True() {
+				return fmt.Errorf("%s can only be set when %s is %q", key, constants.FieldImageSourceType,
+					harvsterv1.VirtualMachineImageSourceTypeExportVolume)
+			}
+		}
+	}
+	if encryption := d.Get(constants.FieldImageEncryption).([]interface{}); len(encryption) > 0 && encryption[0] != nil {
+		if sourceType != string(harvsterv1.VirtualMachineImageSourceTypeClone) {
+			return fmt.Errorf("%s can only be set when %s is %q", constants.FieldImageEncryption, constants.FieldImageSourceType,
+				harvsterv1.VirtualMachineImageSourceTypeClone)
+		}
+		r := encryption[0].(map[string]interface{})
+		if r[constants.FieldImageEncryptionOperation].(string) == encryptionOperationEncrypt {
+			if value := d.GetRawConfig().GetAttr(constants.FieldImageStorageClassName); !value.IsNull() {
+				return fmt.Errorf("%s can't be set to encrypt an image, the encrypted storage class is created", constants.FieldImageStorageClassName)
+			}
+		} else if r[constants.FieldImageEncryptionPassphrase].(string) != "" || r[constants.FieldImageEncryptionSecretName].(string) != "" {
+			return fmt.Errorf("%s and %s can only be set to encrypt an image", constants.FieldImageEncryptionPassphrase, constants.FieldImageEncryptionSecretName)
+		}
+	}
+	if viaProvider && sourceType != string(harvsterv1.VirtualMachineImageSourceTypeDownload) {
+		return fmt.Errorf("%s can only be set when %s is %q", constants.FieldImageDownloadViaProvider,
+			constants.FieldImageSourceType, harvsterv1.VirtualMachineImageSourceTypeDownload)
+	}
+	if !viaProvider {
+		for _, key := range []string{constants.FieldImageDownloadHeaders, constants.FieldImageDownloadCACertificate} {
+			if value := d.GetRawConfig().GetAttr(key); value.IsKnown() && !value.IsNull() {
+				return fmt.Errorf("%s can only be set when %s is true", key, constants.FieldImageDownloadViaProvider)
+			}
+		}
+		// The VirtualMachineImage has no field for credentials, only a presigned url can be downloaded by Harvester.
+		if d.Get(constants.FieldImageDownloadCredentials+".0."+constants.FieldImageCredentialsSecretName).(string) != "" {
+			return fmt.Errorf("%s.%s can only be set when %s is true", constants.FieldImageDownloadCredentials,
+				constants.FieldImageCredentialsSecretName, constants.FieldImageDownloadViaProvider)
+		}
+	}
+	if len(d.Get(constants.FieldImageDownloadCredentials).([]interface{})) > 0 && sourceType != string(harvsterv1.VirtualMachineImageSourceTypeDownload) {
+		return fmt.Errorf("%s can only be set when %s is %q", constants.FieldImageDownloadCredentials,
+			constants.FieldImageSourceType, harvsterv1.VirtualMachineImageSourceTypeDownload)
+	}
+	return nil
+}
+
 func resourceImageCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	c, err := meta.(*config.Config).K8sClient()
 	if err != nil {
@@ -60,17 +117,86 @@ func resourceImageCreate(ctx context.Context, d *schema.ResourceData, meta inter
 	if err != nil {
 		return diag.FromErr(err)
 	}
+
+	// the source of an image which is uploaded by the provider
+	var source *uploadSource
+	checksum := d.Get(constants.FieldImageChecksum).(string)
+	switch {
+	case d.Get(constants.FieldImageSourceType).(string) == string(harvsterv1.VirtualMachineImageSourceTypeUpload):
+		if source, err = fileUploadSource(ctx, d.Get(constants.FieldImageFilePath).(string), checksum); err != nil {
+			return diag.FromErr(err)
+		}
+	case d.Get(constants.FieldImageSourceType).(string) == string(harvsterv1.VirtualMachineImageSourceTypeDownload):
+		download, err := getImageDownload(ctx, c, d, namespace)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if d.Get(constants.FieldImageDownloadViaProvider).(bool) {
+			source = downloadUploadSource(download.URL, download.Headers,
+				d.Get(constants.FieldImageDownloadCACertificate).(string), checksum)
+		} else if image := toCreate.(*harvsterv1.VirtualMachineImage); download.URL != image.Spec.URL {
+			// Harvester downloads the presigned url, the url is kept to be read back
+			image.Annotations[constants.AnnotationImageDownloadURL] = image.Spec.URL
+			image.Spec.URL = download.URL
+		}
+	case len(d.Get(constants.FieldImageEncryption).([]interface{})) > 0:
+		cleanup, err := prepareImageEncryption(ctx, c, d, toCreate.(*harvsterv1.VirtualMachineImage))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		defer func() {
+			if d.Id() == "" {
+				cleanup()
+			}
+		}()
+	case d.Get(constants.FieldImageExportSnapshot).(bool):
+		// the snapshot is exported, it is deleted when the export is done
+		image := toCreate.(*harvsterv1.VirtualMachineImage)
+		exportPVCName, cleanup, err := snapshotVolumeForExport(ctx, c, image.Spec.PVCNamespace, image.Spec.PVCName, name)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		defer cleanup()
+		image.Annotations[constants.AnnotationImageExportPVCName] = image.Spec.PVCName
+		image.Spec.PVCName = exportPVCName
+	}
+
 	_, err = c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(namespace).Create(ctx, toCreate.(*harvsterv1.VirtualMachineImage), metav1.CreateOptions{})
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	d.SetId(helper.BuildID(namespace, name))
 
-	if d.Get(constants.FieldImageSourceType).(string) == string(harvsterv1.VirtualMachineImageSourceTypeUpload) {
-		filePath := d.Get(constants.FieldImageFilePath).(string)
-		if err := uploadImageFile(ctx, c, namespace, name, filePath); err != nil {
-			_ = c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(namespace).Delete(ctx, name, metav1.DeleteOptions{})
-			return diag.FromErr(fmt.Errorf("failed to upload image: %w", err))
+	if source != nil {
+		var uploadTimeout time.Duration
+		if v := d.Get(constants.FieldImageUploadTimeout).(string); v != "" {
+			if uploadTimeout, err = time.ParseDuration(v); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+		// with an upload timeout, the create timeout starts again after the upload
+		uploadCtx, cancel := detachedContext(ctx, uploadTimeout)
+		defer cancel()
+		if err := uploadImage(uploadCtx, c, namespace, name, source, d.Get(constants.FieldImageUploadRetries).(int)); err != nil {
+			diags := diag.FromErr(fmt.Errorf("failed to upload image: %w", err))
+			cleanupCtx, cancel := util.CleanupContext(ctx)
+			defer cancel()
+			err = c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(namespace).Delete(cleanupCtx, name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				// the ID is kept, so the image is replaced by the next apply
+				return append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  fmt.Sprintf("failed to delete image %s after the failed upload", d.Id()),
+					Detail:   err.Error(),
+				})
+			}
+			d.SetId("")
+			return diags
+		}
+		if uploadTimeout > 0 {
+			waitCtx, cancel := detachedContext(ctx, d.Timeout(schema.TimeoutCreate))
+			defer cancel()
+			return diag.FromErr(resourceImageWaitForState(waitCtx, d, meta, schema.TimeoutCreate))
 		}
 	}
 
@@ -122,7 +248,10 @@ func resourceImageRead(ctx context.Context, d *schema.ResourceData, meta interfa
 		}
 		return diag.FromErr(err)
 	}
-	return diag.FromErr(resourceImageImport(d, obj))
+	if err = resourceImageImport(d, obj); err != nil {
+		return diag.FromErr(err)
+	}
+	return setImageUsedBy(ctx, c, d, obj)
 }
 
 func resourceImageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -134,9 +263,37 @@ func resourceImageDelete(ctx context.Context, d *schema.ResourceData, meta inter
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	obj, err := c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	var diags diag.Diagnostics
+	if !d.Get(constants.FieldImageForceDelete).(bool) {
+		usage, err := getImageUsage(ctx, c, obj, "")
+		if apierrors.IsForbidden(err) {
+			// a user limited to the namespace of the image can only check it
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("only the namespace %s is checked for volumes and virtual machines which use image %s", namespace, d.Id()),
+				Detail:   err.Error(),
+			})
+			usage, err = getImageUsage(ctx, c, obj, namespace)
+		}
+		if err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+		if !usage.IsEmpty() {
+			return append(diags, diag.Errorf("image %s is used by %s, delete them first or set %s to delete the image anyway",
+				d.Id(), usage, constants.FieldImageForceDelete)...)
+		}
+	}
 	err = c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	if err != nil && !apierrors.IsNotFound(err) {
-		return diag.FromErr(err)
+		return append(diags, diag.FromErr(err)...)
 	}
 
 	stateConf := &retry.StateChangeConf{
@@ -149,11 +306,33 @@ func resourceImageDelete(ctx context.Context, d *schema.ResourceData, meta inter
 	}
 	_, err = stateConf.WaitForStateContext(ctx)
 	if err != nil {
-		return diag.FromErr(err)
+		return append(diags, diag.FromErr(err)...)
+	}
+	// the secret and storage class of the encryption are kept while volumes use them
+	if encryption, ok := getImageEncryption(obj); ok {
+		diags = append(diags, deleteImageEncryption(ctx, c, encryption)...)
 	}
 
 	d.SetId("")
-	return nil
+	return diags
+}
+
+// setImageUsedBy sets the virtual machines in the namespace of the image which
+// use it, other namespaces are only checked on delete. A user who can't list
+// the volumes or virtual machines gets a warning.
+func setImageUsedBy(ctx context.Context, c *client.Client, d *schema.ResourceData, obj *harvsterv1.VirtualMachineImage) diag.Diagnostics {
+	usage, err := getImageUsage(ctx, c, obj, obj.Namespace)
+	if apierrors.IsForbidden(err) {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("failed to read the virtual machines which use image %s/%s", obj.Namespace, obj.Name),
+			Detail:   err.Error(),
+		}}
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(d.Set(constants.FieldImageUsedBy, usage.VirtualMachines))
 }
 
 func resourceImageImport(d *schema.ResourceData, obj *harvsterv1.VirtualMachineImage) error {
@@ -200,115 +379,13 @@ func resourceImageRefresh(ctx context.Context, d *schema.ResourceData, meta inte
 			message := d.Get(constants.FieldCommonMessage).(string)
 			return obj, state, errors.New(message)
 		}
-		return obj, state, err
-	}
-}
-
-func uploadImageFile(ctx context.Context, c *client.Client, namespace, name, filePath string) error {
-	stat, err := os.Stat(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to stat file %s: %w", filePath, err)
-	}
-
-	// Wait for the Harvester controller to initialize the image before uploading.
-	// The upload action is only available after Initialized=True on the VMI.
-	if err := waitForImageInitialized(ctx, c, namespace, name); err != nil {
-		return err
-	}
-
-	// The upload action is served by Harvester's Steve API, exposed directly at /v1/harvester/.
-	uploadURL := fmt.Sprintf("%s/v1/harvester/harvesterhci.io.virtualmachineimages/%s/%s?action=upload&size=%d",
-		c.RestConfig.Host, namespace, name, stat.Size())
-
-	// Use rest.TransportFor to get a transport pre-configured with TLS, proxy, and auth from kubeconfig.
-	transport, err := rest.TransportFor(c.RestConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create transport: %w", err)
-	}
-
-	// Retry upload on transient errors: "already exists" (HTTP 400) can occur when a previously
-	// deleted image's backing volume hasn't been fully cleaned up yet, and "timeout waiting"
-	// (HTTP 500) can occur when the backing image data source pod needs more time to start.
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-	for {
-		err = doUpload(ctx, transport, uploadURL, filePath)
-		if err == nil {
-			return nil
-		}
-		if !strings.Contains(err.Error(), "already exists") && !strings.Contains(err.Error(), "timeout waiting") {
-			return err
-		}
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("upload failed: %w", err)
-		case <-ticker.C:
+		if state != constants.StateCommonActive {
+			tflog.Info(ctx, "waiting for the image", map[string]interface{}{
+				"image":    d.Id(),
+				"state":    state,
+				"progress": obj.Status.Progress,
+			})
 		}
+		return obj, state, err
 	}
 }
-
-// waitForImageInitialized polls the VirtualMachineImage status until the Initialized
-// condition is True. The Harvester controller must initialize the image (create the
-// backing image and data source) before the Steve API exposes the upload action.
-func waitForImageInitialized(ctx context.Context, c *client.Client, namespace, name string) error {
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		obj, err := c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to check image status: %w", err)
-		}
-		for _, cond := range obj.Status.Conditions {
-			if cond.Type == harvsterv1.ImageInitialized && string(cond.Status) == string(corev1.ConditionTrue) {
-				return nil
-			}
-		}
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("image not initialized after waiting: %w", ctx.Err())
-		case <-ticker.C:
-		}
-	}
-}
-
-// doUpload streams the file to the Harvester upload endpoint as multipart/form-data.
-func doUpload(ctx context.Context, transport http.RoundTripper, uploadURL, filePath string) error {
-	file, err := os.Open(filePath) //nolint:gosec // G304: filePath is user-provided via Terraform configuration
-	if err != nil {
-		return fmt.Errorf("failed to open file %s: %w", filePath, err)
-	}
-
-	// Send only the base filename to avoid leaking the local filesystem path.
-	pr, pw := io.Pipe()
-	writer := multipart.NewWriter(pw)
-	go func() {
-		part, err := writer.CreateFormFile("chunk", filepath.Base(filePath))
-		if err != nil {
-			pw.CloseWithError(err)
-			_ = file.Close()
-			return
-		}
-		_, err = io.Copy(part, file)
-		_ = file.Close()
-		if err != nil {
-			pw.CloseWithError(err)
-			return
-		}
-		pw.CloseWithError(writer.Close())
-	}()
-
-	resp, err := util.DoPostWithTransport(ctx, uploadURL, pr, writer.FormDataContentType(), transport)
-	if err != nil {
-		_ = pr.Close()
-		return fmt.Errorf("upload request failed: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode < http.StatusBadRequest {
-		return nil
-	}
-
-	body, _ := io.ReadAll(resp.Body)
-	return fmt.Errorf("upload failed (HTTP %d): %s", resp.StatusCode, string(body))
-}
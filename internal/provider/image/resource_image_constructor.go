@@ -25,6 +25,7 @@ func (c *Constructor) Setup() util.Processors {
 		Tags(&c.Image.Labels).
 		Labels(&c.Image.Labels).
 		Description(&c.Image.Annotations).
+		DeletionProtection(&c.Image.Annotations).
 		String(constants.FieldImageDisplayName, &c.Image.Spec.DisplayName, true).
 		String(constants.FieldImageSourceType, (*string)(&c.Image.Spec.SourceType), true)
 
@@ -140,6 +141,23 @@ func (c *Constructor) Setup() util.Processors {
 				return nil
 			},
 		},
+		{
+			// The image is downloaded by the provider and uploaded, the url is
+			// kept in an annotation to be read back.
+			Field: constants.FieldImageDownloadViaProvider,
+			Parser: func(i interface{}) error {
+				if !i.(bool) {
+					return nil
+				}
+				if c.Image.Spec.SourceType != harvsterv1.VirtualMachineImageSourceTypeDownload {
+					return errors.New("download_via_provider can only be set when source_type is 'download'")
+				}
+				c.Image.Annotations[constants.AnnotationImageDownloadURL] = c.Image.Spec.URL
+				c.Image.Spec.SourceType = harvsterv1.VirtualMachineImageSourceTypeUpload
+				c.Image.Spec.URL = ""
+				return nil
+			},
+		},
 	}
 	return append(processors, customProcessors...)
 }
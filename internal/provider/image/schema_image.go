@@ -41,12 +41,25 @@ func Schema() map[string]*schema.Schema {
 		constants.FieldImagePVCNamespace: {
 			Type:         schema.TypeString,
 			Optional:     true,
+			ForceNew:     true,
 			ValidateFunc: util.IsValidName,
+			RequiredWith: []string{constants.FieldImagePVCName},
+			Description:  "Namespace of the volume to export. Required when source_type is 'export-from-volume'.",
 		},
 		constants.FieldImagePVCName: {
 			Type:         schema.TypeString,
 			Optional:     true,
+			ForceNew:     true,
 			ValidateFunc: util.IsValidName,
+			RequiredWith: []string{constants.FieldImagePVCNamespace},
+			Description:  "Name of the volume to export. Required when source_type is 'export-from-volume'.",
+		},
+		constants.FieldImageExportSnapshot: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			ForceNew:    true,
+			Description: "Take a snapshot of the volume and export the snapshot, so the volume of a running virtual machine can be exported consistently. Only valid when source_type is 'export-from-volume'.",
 		},
 		constants.FieldImageSourceType: {
 			Type:     schema.TypeString,
@@ -98,13 +111,220 @@ func Schema() map[string]*schema.Schema {
 			Type:        schema.TypeString,
 			Optional:    true,
 			ForceNew:    true,
-			Description: "Local file path to upload (ISO, raw, or qcow2). Required when source_type is 'upload'.",
+			Description: "Local file path to upload (ISO, raw, or qcow2). Required when source_type is 'upload'. Files compressed with gzip, bzip2, xz or zstd are decompressed while they are uploaded, without a temporary copy. A compressed file is read twice, once to compute its decompressed size, which the upload needs before it starts. The SHA-512 checksum of the file, or of the decompressed file, is compared with `checksum` before the upload.",
+		},
+		constants.FieldImageUploadTimeout: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: util.IsPositiveDuration,
+			Description:  "Time limit for the upload of `file_path`, or of `url` when download_via_provider is set, e.g. 4h. The create timeout applies to the other steps. When it is not set, the upload is part of the create timeout.",
+		},
+		constants.FieldImageUploadRetries: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      3,
+			ValidateFunc: validation.IntAtLeast(0),
+			Description:  "How many times an upload which fails with a network error is started again. The upload can't be resumed, it starts from the beginning.",
+		},
+		constants.FieldImageDownloadViaProvider: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			ForceNew:    true,
+			Description: "Download `url` on the host running Terraform and upload it to Harvester, for clusters which can't reach `url`. Only valid when source_type is 'download'. `checksum` is verified during the upload.",
+		},
+		constants.FieldImageDownloadHeaders: {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Sensitive:   true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "HTTP headers sent with the download of `url`, e.g. `Authorization`. Only valid when download_via_provider is set.",
+		},
+		constants.FieldImageDownloadCACertificate: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "PEM encoded CA certificate to verify the server of `url`, in addition to the system CAs. Only valid when download_via_provider is set, Harvester can't be given a CA for the download.",
+		},
+		constants.FieldImageDownloadCredentials: {
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: "Credentials for the download of `url` from an authenticated endpoint. Only valid when source_type is 'download'.",
+			Elem: &schema.Resource{
+				Schema: downloadCredentialsSchema(),
+			},
+		},
+		constants.FieldImageForceDelete: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Delete the image even if it is used by virtual machines or volumes.",
+		},
+		constants.FieldImageUsedBy: {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "The virtual machines in the namespace of the image which use it, as namespace/name. Virtual machines in other namespaces are only checked when the image is deleted.",
+		},
+		constants.FieldImageEncryption: {
+			Type:          schema.TypeList,
+			Optional:      true,
+			ForceNew:      true,
+			MaxItems:      1,
+			ConflictsWith: []string{constants.FieldImageSecurityParameters},
+			Description:   "Clone `source_image` into an encrypted image, or an encrypted image into a decrypted one. The passphrase secret and the encrypted storage class are created for an encryption, and deleted with the image unless volumes still use them. source_type must be 'clone'.",
+			Elem: &schema.Resource{
+				Schema: encryptionSchema(),
+			},
 		},
 	}
 	util.NamespacedSchemaWrap(s, false)
+	util.DeletionProtectionSchemaWrap(s)
 	return s
 }
 
+func encryptionSchema() map[string]*schema.Schema {
+	encryptionKey := func(key string) string {
+		return constants.FieldImageEncryption + ".0." + key
+	}
+	return map[string]*schema.Schema{
+		constants.FieldImageEncryptionOperation: {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+			Default:  encryptionOperationEncrypt,
+			ValidateFunc: validation.StringInSlice([]string{
+				encryptionOperationEncrypt,
+				encryptionOperationDecrypt,
+			}, false),
+			Description: "Either 'encrypt' or 'decrypt'.",
+		},
+		constants.FieldImageEncryptionSourceImage: {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "The image to encrypt or decrypt, as namespace/name or name in the namespace of the image.",
+		},
+		constants.FieldImageEncryptionPassphrase: {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ForceNew:      true,
+			Sensitive:     true,
+			ConflictsWith: []string{encryptionKey(constants.FieldImageEncryptionSecretName)},
+			Description:   "Passphrase of the encryption, a secret is created with it.",
+		},
+		constants.FieldImageEncryptionSecretName: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "Name of an existing secret with the passphrase in the key `" + encryptionSecretKeyValue + "`, and the other keys of a Longhorn encryption secret.",
+		},
+		constants.FieldImageEncryptionSecretNamespace: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "Namespace of the existing secret, the namespace of the image by default.",
+		},
+		constants.FieldImageEncryptionCipher: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Default:     "aes-xts-plain64",
+			Description: "Cipher of the created secret.",
+		},
+		constants.FieldImageEncryptionHash: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Default:     "sha256",
+			Description: "Hash of the created secret.",
+		},
+		constants.FieldImageEncryptionKeySize: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ForceNew:     true,
+			Default:      256,
+			ValidateFunc: validation.IntInSlice([]int{256, 512}),
+			Description:  "Key size in bits of the created secret.",
+		},
+		constants.FieldImageEncryptionPBKDF: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			Default:      "argon2i",
+			ValidateFunc: validation.StringInSlice([]string{"argon2i", "argon2id", "pbkdf2"}, false),
+			Description:  "Password based key derivation function of the created secret.",
+		},
+		constants.FieldImageEncryptionNumberOfReplicas: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ForceNew:     true,
+			Default:      3,
+			ValidateFunc: validation.IntBetween(1, 3),
+			Description:  "Number of replicas of the created storage class.",
+		},
+	}
+}
+
+func downloadCredentialsSchema() map[string]*schema.Schema {
+	credentialsKey := func(key string) string {
+		return constants.FieldImageDownloadCredentials + ".0." + key
+	}
+	s3Keys := []string{
+		credentialsKey(constants.FieldImageCredentialsS3AccessKeyID),
+		credentialsKey(constants.FieldImageCredentialsS3SecretAccessKey),
+	}
+	return map[string]*schema.Schema{
+		constants.FieldImageCredentialsSecretName: {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ValidateFunc:  util.IsValidName,
+			ConflictsWith: s3Keys,
+			Description:   "Name of a secret with the keys `username` and `password` for basic auth, or `token` for a bearer token. Needs download_via_provider, since Harvester can't send credentials.",
+		},
+		constants.FieldImageCredentialsSecretNamespace: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "Namespace of the secret, the namespace of the image by default.",
+		},
+		constants.FieldImageCredentialsS3AccessKeyID: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			RequiredWith: s3Keys,
+			Description:  "S3 access key ID to generate a presigned `url`.",
+		},
+		constants.FieldImageCredentialsS3SecretAccessKey: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Sensitive:    true,
+			RequiredWith: s3Keys,
+			Description:  "S3 secret access key to generate a presigned `url`.",
+		},
+		constants.FieldImageCredentialsS3SessionToken: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Sensitive:   true,
+			Description: "S3 session token of temporary credentials.",
+		},
+		constants.FieldImageCredentialsS3Region: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "us-east-1",
+			Description: "S3 region of the bucket.",
+		},
+		constants.FieldImageCredentialsS3PresignExpiry: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "1h",
+			ValidateFunc: util.IsPositiveDuration,
+			Description:  "How long the presigned `url` is valid, at most 168h. It must cover the download including its retries.",
+		},
+	}
+}
+
 func DataSourceSchema() map[string]*schema.Schema {
 	s := util.DataSourceSchemaWrap(Schema())
 	s[constants.FieldCommonName].Required = false
@@ -113,3 +333,96 @@ func DataSourceSchema() map[string]*schema.Schema {
 	s[constants.FieldImageDisplayName].Optional = true
 	return s
 }
+
+func ImagesDataSourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		constants.FieldCommonNamespace: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "Only images in the namespace, images of all namespaces by default",
+		},
+		constants.FieldImagesDisplayNameRegex: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringIsValidRegExp,
+			Description:  "Only images with a display_name matching the regular expression",
+		},
+		constants.FieldCommonLabels: {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Only images with all the labels",
+		},
+		constants.FieldCommonTags: {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Only images with all the tags",
+		},
+		constants.FieldImagesOSType: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Only images with the OS type, the label or annotation `" + constants.LabelImageOSType + "`",
+		},
+		constants.FieldImageSourceType: {
+			Type:     schema.TypeString,
+			Optional: true,
+			ValidateFunc: validation.StringInSlice([]string{
+				string(harvsterv1.VirtualMachineImageSourceTypeDownload),
+				string(harvsterv1.VirtualMachineImageSourceTypeUpload),
+				string(harvsterv1.VirtualMachineImageSourceTypeExportVolume),
+				string(harvsterv1.VirtualMachineImageSourceTypeClone),
+			}, false),
+			Description: "Only images with the source type",
+		},
+		constants.FieldCommonState: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Only images in the state, e.g. Active",
+		},
+		constants.FieldImagesMostRecent: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Only the most recently created image of the matching images",
+		},
+		constants.FieldImagesIDs: {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "IDs of the matching images, the most recently created first",
+		},
+		constants.FieldImagesImages: {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The matching images, the most recently created first",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id":                                 {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonName:            {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonNamespace:       {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonDescription:     {Type: schema.TypeString, Computed: true},
+					constants.FieldImageDisplayName:      {Type: schema.TypeString, Computed: true},
+					constants.FieldImageSourceType:       {Type: schema.TypeString, Computed: true},
+					constants.FieldImageURL:              {Type: schema.TypeString, Computed: true},
+					constants.FieldImageSize:             {Type: schema.TypeInt, Computed: true},
+					constants.FieldImageStorageClassName: {Type: schema.TypeString, Computed: true},
+					constants.FieldImagesOSType:          {Type: schema.TypeString, Computed: true},
+					constants.FieldImagesCreationTime:    {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonState:           {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonLabels: {
+						Type:     schema.TypeMap,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					constants.FieldCommonTags: {
+						Type:     schema.TypeMap,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,404 @@
+package image
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+)
+
+const (
+	// uploadProgressInterval is how often the progress of an upload is logged.
+	uploadProgressInterval = 30 * time.Second
+	// uploadRetryDelay is the delay before the first retry of a failed upload,
+	// it grows with each retry.
+	uploadRetryDelay = 10 * time.Second
+)
+
+// uploadError is returned when the upload endpoint rejects the upload.
+type uploadError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *uploadError) Error() string {
+	return fmt.Sprintf("upload failed (HTTP %d): %s", e.StatusCode, e.Body)
+}
+
+// detachedContext returns a context with its own timeout, which is only
+// canceled with the parent if the parent is canceled, e.g. on an interrupt,
+// and not when the deadline of the parent passes. A zero timeout returns the
+// parent context.
+func detachedContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return ctx, func() {}
+	}
+	detached, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			cancel()
+		}
+	})
+	return detached, func() {
+		stop()
+		cancel()
+	}
+}
+
+// verifyFileChecksum compares the SHA-512 checksum of the file with the
+// expected checksum, so a corrupted file isn't uploaded.
+func verifyFileChecksum(ctx context.Context, filePath, checksum string) error {
+	file, err := os.Open(filePath) //nolint:gosec // G304: filePath is user-provided via Terraform configuration
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer func() { _ = file.Close() }()
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file %s: %w", filePath, err)
+	}
+
+	hash := sha512.New()
+	reader := newProgressReader(ctx, file, stat.Size(), "computing the SHA-512 checksum of "+filepath.Base(filePath))
+	if _, err = io.Copy(hash, reader); err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(actual, strings.TrimSpace(checksum)) {
+		return fmt.Errorf("the SHA-512 checksum %s of file %s doesn't match the checksum %s", actual, filePath, checksum)
+	}
+	return nil
+}
+
+// uploadSource is the data which is uploaded to an image. Open is called for
+// each attempt, since a failed upload starts again from the beginning.
+type uploadSource struct {
+	// Name is the file name sent with the upload.
+	Name string
+	// Checksum is the SHA-512 checksum which is verified during the upload,
+	// if it is set.
+	Checksum string
+	// Open returns the data and its size in bytes.
+	Open func(ctx context.Context) (io.ReadCloser, int64, error)
+}
+
+// fileUploadSource returns the source to upload a local file. The file is
+// compared with the checksum first. A compressed file is decompressed once to
+// read its size, and again while each attempt streams it.
+func fileUploadSource(ctx context.Context, filePath, checksum string) (*uploadSource, error) {
+	c, err := detectCompression(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	if c == compressionNone {
+		if checksum != "" {
+			if err = verifyFileChecksum(ctx, filePath, checksum); err != nil {
+				return nil, err
+			}
+		}
+		stat, err := os.Stat(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat file %s: %w", filePath, err)
+		}
+		size = stat.Size()
+	} else {
+		tflog.Info(ctx, "the file is compressed, it is decompressed during the upload", map[string]interface{}{
+			"file":        filePath,
+			"compression": string(c),
+		})
+		if size, err = decompressedSize(ctx, filePath, c, checksum); err != nil {
+			return nil, err
+		}
+	}
+	return &uploadSource{
+		// Send only the base filename to avoid leaking the local filesystem path.
+		Name: decompressedName(filePath, c),
+		Open: func(ctx context.Context) (io.ReadCloser, int64, error) {
+			reader, err := openDecompressed(filePath, c)
+			if err != nil {
+				return nil, 0, err
+			}
+			return reader, size, nil
+		},
+	}, nil
+}
+
+// downloadUploadSource returns the source to download imageURL on the host of
+// the provider and upload it. The server must send the Content-Length, since
+// the size of the upload must be known before it starts.
+func downloadUploadSource(imageURL string, headers map[string]string, cacert, checksum string) *uploadSource {
+	name := path.Base(imageURL)
+	if u, err := url.Parse(imageURL); err == nil {
+		name = path.Base(u.Path)
+	}
+	return &uploadSource{
+		Name:     name,
+		Checksum: checksum,
+		Open: func(ctx context.Context) (io.ReadCloser, int64, error) {
+			resp, err := util.DoGetWithHeaders(ctx, imageURL, headers, cacert)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to download %s: %w", imageURL, err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				_ = resp.Body.Close()
+				return nil, 0, &downloadError{URL: imageURL, StatusCode: resp.StatusCode}
+			}
+			if resp.ContentLength < 0 {
+				_ = resp.Body.Close()
+				return nil, 0, fmt.Errorf("failed to download %s: the server didn't send the Content-Length", imageURL)
+			}
+			return resp.Body, resp.ContentLength, nil
+		},
+	}
+}
+
+// downloadError is returned when the server of a download_via_provider url
+// rejects the download.
+type downloadError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *downloadError) Error() string {
+	return fmt.Sprintf("failed to download %s (HTTP %d)", e.URL, e.StatusCode)
+}
+
+// uploadImage uploads the source to the image. Uploads which fail with a
+// transient network error are started again up to retries times, the upload
+// endpoint doesn't support resuming an upload.
+func uploadImage(ctx context.Context, c *client.Client, namespace, name string, source *uploadSource, retries int) error {
+	// Wait for the Harvester controller to initialize the image before uploading.
+	// The upload action is only available after Initialized=True on the VMI.
+	if err := waitForImageInitialized(ctx, c, namespace, name); err != nil {
+		return err
+	}
+
+	// The upload action is served by Harvester's Steve API, exposed directly at /v1/harvester/.
+	uploadURL := fmt.Sprintf("%s/v1/harvester/harvesterhci.io.virtualmachineimages/%s/%s?action=upload",
+		c.RestConfig.Host, namespace, name)
+
+	// Use rest.TransportFor to get a transport pre-configured with TLS, proxy, and auth from kubeconfig.
+	transport, err := rest.TransportFor(c.RestConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create transport: %w", err)
+	}
+
+	// Retry upload on transient errors: "already exists" (HTTP 400) can occur when a previously
+	// deleted image's backing volume hasn't been fully cleaned up yet, and "timeout waiting"
+	// (HTTP 500) can occur when the backing image data source pod needs more time to start.
+	// Neither of them counts as a retry, since no data was transferred.
+	attempt := 0
+	for {
+		err = doUpload(ctx, transport, uploadURL, source)
+		if err == nil {
+			return nil
+		}
+		delay := 5 * time.Second
+		if !strings.Contains(err.Error(), "already exists") && !strings.Contains(err.Error(), "timeout waiting") {
+			if !isTransientUploadError(err) || attempt >= retries {
+				return err
+			}
+			attempt++
+			delay = time.Duration(attempt) * uploadRetryDelay
+			tflog.Warn(ctx, "image upload failed, starting it again", map[string]interface{}{
+				"image":   namespace + "/" + name,
+				"attempt": attempt,
+				"retries": retries,
+				"delay":   delay.String(),
+				"error":   err.Error(),
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("upload failed: %w", err)
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isTransientUploadError reports whether the upload failed because of the
+// network or an unavailable endpoint, so starting it again can succeed.
+func isTransientUploadError(err error) bool {
+	var uploadErr *uploadError
+	if errors.As(err, &uploadErr) {
+		return isTransientStatusCode(uploadErr.StatusCode)
+	}
+	var downloadErr *downloadError
+	if errors.As(err, &downloadErr) {
+		return isTransientStatusCode(downloadErr.StatusCode)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+func isTransientStatusCode(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// waitForImageInitialized polls the VirtualMachineImage status until the Initialized
+// condition is True. The Harvester controller must initialize the image (create the
+// backing image and data source) before the Steve API exposes the upload action.
+func waitForImageInitialized(ctx context.Context, c *client.Client, namespace, name string) error {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		obj, err := c.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to check image status: %w", err)
+		}
+		for _, cond := range obj.Status.Conditions {
+			if cond.Type == harvsterv1.ImageInitialized && string(cond.Status) == string(corev1.ConditionTrue) {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("image not initialized after waiting: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// doUpload streams the source to the Harvester upload endpoint as multipart/form-data.
+func doUpload(ctx context.Context, transport http.RoundTripper, uploadURL string, source *uploadSource) error {
+	data, size, err := source.Open(ctx)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	writeErr := make(chan error, 1)
+	go func() {
+		err := writeUploadPart(ctx, writer, data, size, source)
+		_ = data.Close()
+		writeErr <- err
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(writer.Close())
+	}()
+
+	resp, err := util.DoPostWithTransport(ctx, fmt.Sprintf("%s&size=%d", uploadURL, size), pr, writer.FormDataContentType(), transport)
+	if err != nil {
+		_ = pr.Close()
+		// report why the data couldn't be read, e.g. a checksum mismatch
+		select {
+		case werr := <-writeErr:
+			if werr != nil {
+				return werr
+			}
+		default:
+		}
+		return fmt.Errorf("upload request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	return &uploadError{StatusCode: resp.StatusCode, Body: string(body)}
+}
+
+// writeUploadPart copies the data into the multipart form, the checksum of
+// the source is verified before the form is completed, so a mismatch fails
+// the upload.
+func writeUploadPart(ctx context.Context, writer *multipart.Writer, data io.Reader, size int64, source *uploadSource) error {
+	part, err := writer.CreateFormFile("chunk", source.Name)
+	if err != nil {
+		return err
+	}
+	hash := sha512.New()
+	var reader io.Reader = newProgressReader(ctx, data, size, "uploading "+source.Name)
+	if source.Checksum != "" {
+		reader = io.TeeReader(reader, hash)
+	}
+	written, err := io.Copy(part, reader)
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("read %d bytes of %s, expected %d: %w", written, source.Name, size, io.ErrUnexpectedEOF)
+	}
+	if source.Checksum == "" {
+		return nil
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(actual, strings.TrimSpace(source.Checksum)) {
+		return fmt.Errorf("the SHA-512 checksum %s of %s doesn't match the checksum %s", actual, source.Name, source.Checksum)
+	}
+	return nil
+}
+
+// progressReader logs how much of the reader is read at intervals.
+type progressReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	total   int64
+	message string
+
+	read    int64
+	start   time.Time
+	lastLog time.Time
+}
+
+func newProgressReader(ctx context.Context, reader io.Reader, total int64, message string) *progressReader {
+	now := time.Now()
+	return &progressReader{
+		ctx:     ctx,
+		reader:  reader,
+		total:   total,
+		message: message,
+		start:   now,
+		lastLog: now,
+	}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if now := time.Now(); now.Sub(r.lastLog) >= uploadProgressInterval || (err == io.EOF && r.read > 0) {
+		r.lastLog = now
+		fields := map[string]interface{}{
+			"bytes":         r.read,
+			"total_bytes":   r.total,
+			"bytes_per_sec": int64(float64(r.read) / now.Sub(r.start).Seconds()),
+		}
+		if r.total > 0 {
+			fields["percent"] = r.read * 100 / r.total
+		}
+		tflog.Info(r.ctx, r.message, fields)
+	}
+	return n, err
+}
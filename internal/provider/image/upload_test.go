@@ -0,0 +1,319 @@
+package image
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsTransientUploadError(t *testing.T) {
+	testCases := []struct {
+		name        string
+		err         error
+		expectation bool
+	}{
+		{
+			name:        "service unavailable",
+			err:         &uploadError{StatusCode: http.StatusServiceUnavailable},
+			expectation: true,
+		},
+		{
+			name:        "bad gateway download",
+			err:         fmt.Errorf("wrapped: %w", &downloadError{URL: "https://example.com/a.img", StatusCode: http.StatusBadGateway}),
+			expectation: true,
+		},
+		{
+			name:        "forbidden",
+			err:         &uploadError{StatusCode: http.StatusForbidden},
+			expectation: false,
+		},
+		{
+			name:        "not found download",
+			err:         &downloadError{URL: "https://example.com/a.img", StatusCode: http.StatusNotFound},
+			expectation: false,
+		},
+		{
+			name:        "network error",
+			err:         &net.OpError{Op: "read", Err: errors.New("connection refused")},
+			expectation: true,
+		},
+		{
+			name:        "unexpected eof",
+			err:         fmt.Errorf("read 1 bytes: %w", io.ErrUnexpectedEOF),
+			expectation: true,
+		},
+		{
+			name:        "connection reset",
+			err:         fmt.Errorf("write: %w", syscall.ECONNRESET),
+			expectation: true,
+		},
+		{
+			name:        "checksum mismatch",
+			err:         errors.New("the SHA-512 checksum doesn't match"),
+			expectation: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isTransientUploadError(tc.err); actual != tc.expectation {
+				t.Errorf("expected %t, got %t", tc.expectation, actual)
+			}
+		})
+	}
+}
+
+func TestDetachedContext(t *testing.T) {
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelParent()
+	detached, cancel := detachedContext(parent, time.Minute)
+	defer cancel()
+	<-parent.Done()
+	if detached.Err() != nil {
+		t.Errorf("expected the detached context to outlive the deadline of the parent, got %v", detached.Err())
+	}
+
+	parent, cancelParent = context.WithCancel(context.Background())
+	detached, cancel = detachedContext(parent, time.Minute)
+	defer cancel()
+	cancelParent()
+	select {
+	case <-detached.Done():
+	case <-time.After(time.Second):
+		t.Error("expected the detached context to be canceled with the parent")
+	}
+
+	parent = context.Background()
+	if detached, _ = detachedContext(parent, 0); detached != parent {
+		t.Error("expected the parent context for a zero timeout")
+	}
+}
+
+func TestDoUpload(t *testing.T) {
+	data := "image data"
+	sum := sha512.Sum512([]byte(data))
+	checksum := hex.EncodeToString(sum[:])
+
+	testCases := []struct {
+		name        string
+		statusCode  int
+		checksum    string
+		size        int64
+		expectation string
+	}{
+		{
+			name:       "uploaded",
+			statusCode: http.StatusOK,
+			checksum:   checksum,
+			size:       int64(len(data)),
+		},
+		{
+			name:        "rejected",
+			statusCode:  http.StatusServiceUnavailable,
+			size:        int64(len(data)),
+			expectation: "upload failed (HTTP 503): unavailable",
+		},
+		{
+			name:        "checksum mismatch",
+			statusCode:  http.StatusOK,
+			checksum:    strings.Repeat("0", 128),
+			size:        int64(len(data)),
+			expectation: "doesn't match the checksum",
+		},
+		{
+			name:        "truncated",
+			statusCode:  http.StatusOK,
+			size:        int64(len(data)) + 1,
+			expectation: io.ErrUnexpectedEOF.Error(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				if size := r.URL.Query().Get("size"); size != fmt.Sprint(tc.size) {
+					t.Errorf("expected size %d, got %s", tc.size, size)
+				}
+				w.WriteHeader(tc.statusCode)
+				if tc.statusCode != http.StatusOK {
+					_, _ = io.WriteString(w, "unavailable")
+				}
+			}))
+			defer server.Close()
+
+			source := &uploadSource{
+				Name:     "test.img",
+				Checksum: tc.checksum,
+				Open: func(context.Context) (io.ReadCloser, int64, error) {
+					return io.NopCloser(strings.NewReader(data)), tc.size, nil
+				},
+			}
+			err := doUpload(context.Background(), http.DefaultTransport, server.URL+"?action=upload", source)
+			if tc.expectation == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectation) {
+				t.Errorf("expected an error containing %q, got %v", tc.expectation, err)
+			}
+		})
+	}
+}
+
+// TestDownloadUploadSource streams a download of the provider into the upload
+// endpoint, as download_via_provider does.
+func TestDownloadUploadSource(t *testing.T) {
+	data := "image data"
+	sum := sha512.Sum512([]byte(data))
+	checksum := hex.EncodeToString(sum[:])
+
+	download := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer download" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/images/leap.qcow2":
+			_, _ = io.WriteString(w, data)
+		case "/images/chunked.qcow2":
+			// flushing before the body is written sends it without the Content-Length
+			w.(http.Flusher).Flush()
+			_, _ = io.WriteString(w, data)
+		case "/images/unavailable.qcow2":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer download.Close()
+
+	testCases := []struct {
+		name        string
+		url         string
+		headers     map[string]string
+		checksum    string
+		expectation string
+		transient   bool
+	}{
+		{
+			name:     "streamed",
+			url:      download.URL + "/images/leap.qcow2?token=abc",
+			headers:  map[string]string{"Authorization": "Bearer download"},
+			checksum: checksum,
+		},
+		{
+			name:        "checksum mismatch",
+			url:         download.URL + "/images/leap.qcow2",
+			headers:     map[string]string{"Authorization": "Bearer download"},
+			checksum:    strings.Repeat("0", 128),
+			expectation: "doesn't match the checksum",
+		},
+		{
+			name:        "without credentials",
+			url:         download.URL + "/images/leap.qcow2",
+			expectation: "(HTTP 401)",
+		},
+		{
+			name:        "not found",
+			url:         download.URL + "/images/missing.qcow2",
+			headers:     map[string]string{"Authorization": "Bearer download"},
+			expectation: "(HTTP 404)",
+		},
+		{
+			name:        "unavailable",
+			url:         download.URL + "/images/unavailable.qcow2",
+			headers:     map[string]string{"Authorization": "Bearer download"},
+			expectation: "(HTTP 503)",
+			transient:   true,
+		},
+		{
+			name:        "without content length",
+			url:         download.URL + "/images/chunked.qcow2",
+			headers:     map[string]string{"Authorization": "Bearer download"},
+			expectation: "didn't send the Content-Length",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var uploaded string
+			upload := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if size := r.URL.Query().Get("size"); size != fmt.Sprint(len(data)) {
+					t.Errorf("expected size %d, got %s", len(data), size)
+				}
+				file, header, err := r.FormFile("chunk")
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				defer func() { _ = file.Close() }()
+				if header.Filename != "leap.qcow2" {
+					t.Errorf("expected the file name leap.qcow2, got %s", header.Filename)
+				}
+				body, _ := io.ReadAll(file)
+				uploaded = string(body)
+			}))
+			defer upload.Close()
+
+			source := downloadUploadSource(tc.url, tc.headers, "", tc.checksum)
+			err := doUpload(context.Background(), http.DefaultTransport, upload.URL+"?action=upload", source)
+			if tc.expectation == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if uploaded != data {
+					t.Errorf("expected the upload %q, got %q", data, uploaded)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectation) {
+				t.Fatalf("expected an error containing %q, got %v", tc.expectation, err)
+			}
+			if actual := isTransientUploadError(err); actual != tc.transient {
+				t.Errorf("expected transient=%t, got %t", tc.transient, actual)
+			}
+		})
+	}
+}
+
+// TestDownloadUploadSourceCACertificate downloads from a TLS server, which is
+// only trusted with download_ca_certificate.
+func TestDownloadUploadSourceCACertificate(t *testing.T) {
+	download := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "image data")
+	}))
+	defer download.Close()
+	cacert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: download.Certificate().Raw}))
+
+	for _, trusted := range []bool{true, false} {
+		source := downloadUploadSource(download.URL+"/leap.qcow2", nil, "", "")
+		if trusted {
+			source = downloadUploadSource(download.URL+"/leap.qcow2", nil, cacert, "")
+		}
+		reader, size, err := source.Open(context.Background())
+		if trusted != (err == nil) {
+			t.Fatalf("trusted=%t: expected error=%t, got %v", trusted, !trusted, err)
+		}
+		if err != nil {
+			continue
+		}
+		_ = reader.Close()
+		if size != int64(len("image data")) {
+			t.Errorf("expected the size %d, got %d", len("image data"), size)
+		}
+	}
+}
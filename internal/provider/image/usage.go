@@ -0,0 +1,122 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/builder"
+	harvsterutil "github.com/harvester/harvester/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+)
+
+// imageUsage is what uses an image, as namespace/name.
+type imageUsage struct {
+	// VirtualMachines have a volume or a volume claim template of the image.
+	VirtualMachines []string
+	// Volumes of the image are not used by a virtual machine.
+	Volumes []string
+}
+
+func (u *imageUsage) IsEmpty() bool {
+	return len(u.VirtualMachines) == 0 && len(u.Volumes) == 0
+}
+
+func (u *imageUsage) String() string {
+	var parts []string
+	if len(u.VirtualMachines) > 0 {
+		parts = append(parts, "the virtual machines "+strings.Join(u.VirtualMachines, ", "))
+	}
+	if len(u.Volumes) > 0 {
+		parts = append(parts, "the volumes "+strings.Join(u.Volumes, ", "))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// getImageUsage lists the volumes and virtual machines in the namespace, or
+// in all namespaces if it is empty, and returns those which use the image.
+func getImageUsage(ctx context.Context, c *client.Client, image *harvsterv1.VirtualMachineImage, namespace string) (*imageUsage, error) {
+	pvcs, err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	vms, err := c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list virtual machines: %w", err)
+	}
+	return imageUsageOf(image, pvcs.Items, vms.Items)
+}
+
+// imageUsageOf finds the volumes created from the image, by their image ID
+// annotation or the storage class of the backing image, and the virtual
+// machines which use them or have a volume claim template of the image.
+func imageUsageOf(image *harvsterv1.VirtualMachineImage, pvcs []corev1.PersistentVolumeClaim, vms []kubevirtv1.VirtualMachine) (*imageUsage, error) {
+	imageID := helper.BuildNamespacedName(image.Namespace, image.Name)
+	// the storage class is only specific to the image with the backing image backend
+	var storageClassName string
+	if image.Spec.Backend != harvsterv1.VMIBackendCDI {
+		storageClassName = image.Status.StorageClassName
+	}
+	isImageVolume := func(namespace string, annotations map[string]string, volumeStorageClassName *string) bool {
+		if id := annotations[builder.AnnotationKeyImageID]; id != "" {
+			if rebuilt, err := helper.RebuildNamespacedName(id, namespace); err == nil && rebuilt == imageID {
+				return true
+			}
+		}
+		return storageClassName != "" && volumeStorageClassName != nil && *volumeStorageClassName == storageClassName
+	}
+
+	volumes := map[string]bool{}
+	for _, pvc := range pvcs {
+		if isImageVolume(pvc.Namespace, pvc.Annotations, pvc.Spec.StorageClassName) {
+			volumes[helper.BuildNamespacedName(pvc.Namespace, pvc.Name)] = false
+		}
+	}
+
+	usage := &imageUsage{}
+	for _, vm := range vms {
+		used := false
+		if vm.Spec.Template != nil {
+			for _, volume := range vm.Spec.Template.Spec.Volumes {
+				if volume.PersistentVolumeClaim == nil {
+					continue
+				}
+				volumeID := helper.BuildNamespacedName(vm.Namespace, volume.PersistentVolumeClaim.ClaimName)
+				if _, ok := volumes[volumeID]; ok {
+					volumes[volumeID] = true
+					used = true
+				}
+			}
+		}
+		if volumeClaimTemplates := vm.Annotations[harvsterutil.AnnotationVolumeClaimTemplates]; volumeClaimTemplates != "" {
+			var pvcTemplates []*corev1.PersistentVolumeClaim
+			if err := json.Unmarshal([]byte(volumeClaimTemplates), &pvcTemplates); err != nil {
+				return nil, fmt.Errorf("failed to parse the volume claim templates of virtual machine %s/%s: %w", vm.Namespace, vm.Name, err)
+			}
+			for _, pvcTemplate := range pvcTemplates {
+				if isImageVolume(vm.Namespace, pvcTemplate.Annotations, pvcTemplate.Spec.StorageClassName) {
+					used = true
+				}
+			}
+		}
+		if used {
+			usage.VirtualMachines = append(usage.VirtualMachines, helper.BuildNamespacedName(vm.Namespace, vm.Name))
+		}
+	}
+	for volumeID, usedByVM := range volumes {
+		if !usedByVM {
+			usage.Volumes = append(usage.Volumes, volumeID)
+		}
+	}
+	sort.Strings(usage.VirtualMachines)
+	sort.Strings(usage.Volumes)
+	return usage, nil
+}
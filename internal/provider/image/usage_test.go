@@ -0,0 +1,158 @@
+package image
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/builder"
+	harvsterutil "github.com/harvester/harvester/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func testImageVolume(namespace, name, imageID, storageClassName string) corev1.PersistentVolumeClaim {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: map[string]string{},
+		},
+	}
+	if imageID != "" {
+		pvc.Annotations[builder.AnnotationKeyImageID] = imageID
+	}
+	if storageClassName != "" {
+		pvc.Spec.StorageClassName = &storageClassName
+	}
+	return pvc
+}
+
+func testVirtualMachine(namespace, name, volumeClaimTemplates string, claimNames ...string) kubevirtv1.VirtualMachine {
+	vm := kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: map[string]string{},
+		},
+		Spec: kubevirtv1.VirtualMachineSpec{
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{},
+		},
+	}
+	if volumeClaimTemplates != "" {
+		vm.Annotations[harvsterutil.AnnotationVolumeClaimTemplates] = volumeClaimTemplates
+	}
+	for _, claimName := range claimNames {
+		vm.Spec.Template.Spec.Volumes = append(vm.Spec.Template.Spec.Volumes, kubevirtv1.Volume{
+			Name: claimName,
+			VolumeSource: kubevirtv1.VolumeSource{
+				PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+				},
+			},
+		})
+	}
+	return vm
+}
+
+func TestImageUsageOf(t *testing.T) {
+	image := &harvsterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "images",
+			Name:      "leap",
+		},
+		Status: harvsterv1.VirtualMachineImageStatus{
+			StorageClassName: "longhorn-leap",
+		},
+	}
+	cdiImage := image.DeepCopy()
+	cdiImage.Spec.Backend = harvsterv1.VMIBackendCDI
+
+	pvcs := []corev1.PersistentVolumeClaim{
+		testImageVolume("default", "vm1-rootdisk", "images/leap", ""),
+		testImageVolume("default", "vm2-rootdisk", "", "longhorn-leap"),
+		testImageVolume("images", "unused", "leap", ""),
+		testImageVolume("default", "other", "images/ubuntu", "longhorn-ubuntu"),
+	}
+	vms := []kubevirtv1.VirtualMachine{
+		testVirtualMachine("default", "vm1", "", "vm1-rootdisk", "other"),
+		testVirtualMachine("default", "vm2", "", "vm2-rootdisk"),
+		testVirtualMachine("default", "vm3", fmt.Sprintf(`[{"metadata":{"name":"vm3-rootdisk","annotations":{%q:"images/leap"}}}]`, builder.AnnotationKeyImageID)),
+		testVirtualMachine("default", "vm4", "", "other"),
+	}
+
+	testCases := []struct {
+		name        string
+		image       *harvsterv1.VirtualMachineImage
+		pvcs        []corev1.PersistentVolumeClaim
+		vms         []kubevirtv1.VirtualMachine
+		expectation *imageUsage
+		expectError bool
+	}{
+		{
+			name:  "backing image",
+			image: image,
+			pvcs:  pvcs,
+			vms:   vms,
+			expectation: &imageUsage{
+				VirtualMachines: []string{"default/vm1", "default/vm2", "default/vm3"},
+				Volumes:         []string{"images/unused"},
+			},
+		},
+		{
+			name:  "cdi image isn't matched by the storage class",
+			image: cdiImage,
+			pvcs:  pvcs,
+			vms:   vms,
+			expectation: &imageUsage{
+				VirtualMachines: []string{"default/vm1", "default/vm3"},
+				Volumes:         []string{"images/unused"},
+			},
+		},
+		{
+			name:        "unused",
+			image:       image,
+			pvcs:        pvcs[3:],
+			vms:         vms[3:],
+			expectation: &imageUsage{},
+		},
+		{
+			name:        "invalid volume claim templates",
+			image:       image,
+			vms:         []kubevirtv1.VirtualMachine{testVirtualMachine("default", "vm5", "{")},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			usage, err := imageUsageOf(tc.image, tc.pvcs, tc.vms)
+			if tc.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(usage, tc.expectation) {
+				t.Errorf("expected %+v, got %+v", tc.expectation, usage)
+			}
+		})
+	}
+}
+
+func TestImageUsageString(t *testing.T) {
+	usage := &imageUsage{
+		VirtualMachines: []string{"default/vm1", "default/vm2"},
+		Volumes:         []string{"default/disk"},
+	}
+	if expectation := "the virtual machines default/vm1, default/vm2 and the volumes default/disk"; usage.String() != expectation {
+		t.Errorf("expected %q, got %q", expectation, usage.String())
+	}
+	if !(&imageUsage{}).IsEmpty() || usage.IsEmpty() {
+		t.Error("expected only the usage without virtual machines and volumes to be empty")
+	}
+}
@@ -0,0 +1,184 @@
+package longhornrecurringjob
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+	"github.com/harvester/terraform-provider-harvester/pkg/importer"
+)
+
+func ResourceLonghornRecurringJob() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceLonghornRecurringJobCreate,
+		ReadContext:   resourceLonghornRecurringJobRead,
+		DeleteContext: resourceLonghornRecurringJobDelete,
+		UpdateContext: resourceLonghornRecurringJobUpdate,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema:        Schema(),
+		CustomizeDiff: resourceLonghornRecurringJobCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create:  schema.DefaultTimeout(2 * time.Minute),
+			Read:    schema.DefaultTimeout(2 * time.Minute),
+			Update:  schema.DefaultTimeout(2 * time.Minute),
+			Delete:  schema.DefaultTimeout(2 * time.Minute),
+			Default: schema.DefaultTimeout(2 * time.Minute),
+		},
+	}
+}
+
+func resourceLonghornRecurringJobCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	switch task := d.Get(constants.FieldLonghornRecurringJobTask).(string); task {
+	case constants.LonghornRecurringJobTaskSnapshot, constants.LonghornRecurringJobTaskSnapshotForceCreate,
+		constants.LonghornRecurringJobTaskBackup, constants.LonghornRecurringJobTaskBackupForceCreate:
+		if d.NewValueKnown(constants.FieldLonghornRecurringJobRetain) && d.Get(constants.FieldLonghornRecurringJobRetain).(int) < 1 {
+			return fmt.Errorf("%s must be at least 1 when %s is %q", constants.FieldLonghornRecurringJobRetain, constants.FieldLonghornRecurringJobTask, task)
+		}
+	}
+	return nil
+}
+
+func resourceLonghornRecurringJobCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	name := d.Get(constants.FieldCommonName).(string)
+	toCreate, err := util.ResourceConstruct(ctx, d, Creator(name))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if _, err = c.DynamicClient.Resource(util.LonghornRecurringJobGVR).Namespace(constants.LonghornNamespace).Create(ctx, toCreate.(*unstructured.Unstructured), metav1.CreateOptions{}); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(helper.BuildID("", name))
+	return resourceLonghornRecurringJobRead(ctx, d, meta)
+}
+
+func resourceLonghornRecurringJobUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	_, name, err := helper.IDParts(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	obj, err := c.DynamicClient.Resource(util.LonghornRecurringJobGVR).Namespace(constants.LonghornNamespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	toUpdate, err := util.ResourceConstruct(ctx, d, Updater(obj))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if _, err = c.DynamicClient.Resource(util.LonghornRecurringJobGVR).Namespace(constants.LonghornNamespace).Update(ctx, toUpdate.(*unstructured.Unstructured), metav1.UpdateOptions{}); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceLonghornRecurringJobRead(ctx, d, meta)
+}
+
+func resourceLonghornRecurringJobRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	_, name, err := helper.IDParts(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	obj, err := c.DynamicClient.Resource(util.LonghornRecurringJobGVR).Namespace(constants.LonghornNamespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(resourceLonghornRecurringJobImport(d, obj))
+}
+
+func resourceLonghornRecurringJobDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	_, name, err := helper.IDParts(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err = c.DynamicClient.Resource(util.LonghornRecurringJobGVR).Namespace(constants.LonghornNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+
+	stateConf := &retry.StateChangeConf{
+		Pending:    []string{constants.StateCommonActive},
+		Target:     []string{constants.StateCommonRemoved},
+		Refresh:    resourceLonghornRecurringJobRefresh(ctx, c, name),
+		Timeout:    d.Timeout(schema.TimeoutDelete),
+		Delay:      1 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+	if _, err = stateConf.WaitForStateContext(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func resourceLonghornRecurringJobImport(d *schema.ResourceData, obj *unstructured.Unstructured) error {
+	stateGetter, err := importer.ResourceLonghornRecurringJobStateGetter(obj)
+	if err != nil {
+		return err
+	}
+	return util.ResourceStatesSet(d, stateGetter)
+}
+
+func resourceLonghornRecurringJobRefresh(ctx context.Context, c *client.Client, name string) retry.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		obj, err := c.DynamicClient.Resource(util.LonghornRecurringJobGVR).Namespace(constants.LonghornNamespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return obj, constants.StateCommonRemoved, nil
+			}
+			return obj, constants.StateCommonError, err
+		}
+		return obj, constants.StateCommonActive, nil
+	}
+}
+
+// validateCron checks the schedule has the five fields of the cron format,
+// or is a predefined schedule like @daily.
+func validateCron(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %q to be string", k)}
+	}
+	if strings.HasPrefix(v, "@") {
+		return nil, nil
+	}
+	if fields := strings.Fields(v); len(fields) != 5 {
+		return nil, []error{fmt.Errorf("expected %q to be a cron schedule with 5 fields, e.g. \"0 2 * * *\", got %q", k, v)}
+	}
+	return nil, nil
+}
@@ -0,0 +1,113 @@
+package longhornrecurringjob
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+var (
+	_ util.Constructor = &Constructor{}
+)
+
+// Constructor builds the RecurringJob, which has no typed client, so the
+// metadata and the groups are processed apart and set on the object in Result.
+type Constructor struct {
+	RecurringJob *unstructured.Unstructured
+	Labels       map[string]string
+	Annotations  map[string]string
+	Groups       []interface{}
+}
+
+func (c *Constructor) Setup() util.Processors {
+	processors := util.NewProcessors().
+		Tags(&c.Labels).
+		Labels(&c.Labels).
+		Description(&c.Annotations).
+		DeletionProtection(&c.Annotations)
+
+	customProcessors := []util.Processor{
+		{
+			Field: constants.FieldLonghornRecurringJobTask,
+			Parser: func(i interface{}) error {
+				return unstructured.SetNestedField(c.RecurringJob.Object, i.(string), "spec", "task")
+			},
+			Required: true,
+		},
+		{
+			Field: constants.FieldLonghornRecurringJobCron,
+			Parser: func(i interface{}) error {
+				return unstructured.SetNestedField(c.RecurringJob.Object, i.(string), "spec", "cron")
+			},
+			Required: true,
+		},
+		{
+			Field: constants.FieldLonghornRecurringJobRetain,
+			Parser: func(i interface{}) error {
+				return unstructured.SetNestedField(c.RecurringJob.Object, int64(i.(int)), "spec", "retain")
+			},
+			Required: true,
+		},
+		{
+			Field: constants.FieldLonghornRecurringJobConcurrency,
+			Parser: func(i interface{}) error {
+				return unstructured.SetNestedField(c.RecurringJob.Object, int64(i.(int)), "spec", "concurrency")
+			},
+			Required: true,
+		},
+		{
+			Field: constants.FieldLonghornRecurringJobGroups,
+			Parser: func(i interface{}) error {
+				c.Groups = append(c.Groups, i.(string))
+				return nil
+			},
+		},
+		{
+			Field: constants.FieldLonghornRecurringJobSnapshotLabels,
+			Parser: func(i interface{}) error {
+				return unstructured.SetNestedField(c.RecurringJob.Object, i.(map[string]interface{}), "spec", "labels")
+			},
+			Required: true,
+		},
+	}
+	return append(processors, customProcessors...)
+}
+
+func (c *Constructor) Validate() error {
+	return nil
+}
+
+func (c *Constructor) Result() (interface{}, error) {
+	c.RecurringJob.SetLabels(c.Labels)
+	c.RecurringJob.SetAnnotations(c.Annotations)
+	if err := unstructured.SetNestedSlice(c.RecurringJob.Object, c.Groups, "spec", "groups"); err != nil {
+		return nil, err
+	}
+	return c.RecurringJob, nil
+}
+
+func newLonghornRecurringJobConstructor(recurringJob *unstructured.Unstructured) util.Constructor {
+	annotations := recurringJob.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	labels := recurringJob.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return &Constructor{
+		RecurringJob: recurringJob,
+		Labels:       labels,
+		Annotations:  annotations,
+		Groups:       []interface{}{},
+	}
+}
+
+func Creator(name string) util.Constructor {
+	return newLonghornRecurringJobConstructor(util.NewLonghornRecurringJob(name))
+}
+
+func Updater(recurringJob *unstructured.Unstructured) util.Constructor {
+	return newLonghornRecurringJobConstructor(recurringJob)
+}
@@ -0,0 +1,66 @@
+package longhornrecurringjob
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func Schema() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		constants.FieldLonghornRecurringJobTask: {
+			Type:     schema.TypeString,
+			Required: true,
+			ValidateFunc: validation.StringInSlice([]string{
+				constants.LonghornRecurringJobTaskSnapshot,
+				constants.LonghornRecurringJobTaskSnapshotForceCreate,
+				constants.LonghornRecurringJobTaskSnapshotCleanup,
+				constants.LonghornRecurringJobTaskSnapshotDelete,
+				constants.LonghornRecurringJobTaskBackup,
+				constants.LonghornRecurringJobTaskBackupForceCreate,
+				constants.LonghornRecurringJobTaskFilesystemTrim,
+			}, false),
+		},
+		constants.FieldLonghornRecurringJobCron: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validateCron,
+			Description:  "The schedule of the job in the cron format, e.g. `0 2 * * *`",
+		},
+		constants.FieldLonghornRecurringJobRetain: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      1,
+			ValidateFunc: validation.IntAtLeast(0),
+			Description:  "The number of snapshots or backups to keep, which is at least 1 for the snapshot and backup tasks",
+		},
+		constants.FieldLonghornRecurringJobConcurrency: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      1,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "The number of volumes the job runs on at the same time",
+		},
+		constants.FieldLonghornRecurringJobGroups: {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "The groups of the job, volumes are assigned to the groups with the recurring_job_groups of volumes or the recurring_job_selector of storage classes. Volumes without any recurring jobs are in the group `" + constants.LonghornRecurringJobGroupDefault + "`",
+		},
+		constants.FieldLonghornRecurringJobSnapshotLabels: {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "The labels of the snapshots and backups taken by the job",
+		},
+		constants.FieldLonghornRecurringJobExecutionCount: {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+	}
+	util.NonNamespacedSchemaWrap(s)
+	util.DeletionProtectionSchemaWrap(s)
+	return s
+}
@@ -33,7 +33,8 @@ func (c *Constructor) Setup() util.Processors {
 	processors := util.NewProcessors().
 		Tags(&c.Network.Labels).
 		Labels(&c.Network.Labels).
-		Description(&c.Network.Annotations)
+		Description(&c.Network.Annotations).
+		DeletionProtection(&c.Network.Annotations)
 
 	customProcessors := []util.Processor{
 		{
@@ -62,6 +62,7 @@ func Schema() map[string]*schema.Schema {
 		},
 	}
 	util.NamespacedSchemaWrap(s, false)
+	util.DeletionProtectionSchemaWrap(s)
 	s[constants.FieldCommonLabels].Computed = true // labels may be updated by API server
 	return s
 }
@@ -2,12 +2,14 @@ package provider
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/mitchellh/go-homedir"
 
 	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/provider/apitoken"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/bootstrap"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/cloudinitsecret"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/clusternetwork"
@@ -15,6 +17,7 @@ import (
 	"github.com/harvester/terraform-provider-harvester/internal/provider/ippool"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/keypair"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/loadbalancer"
+	"github.com/harvester/terraform-provider-harvester/internal/provider/longhornrecurringjob"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/network"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/pcidevice"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/schedulebackup"
@@ -24,6 +27,9 @@ import (
 	"github.com/harvester/terraform-provider-harvester/internal/provider/virtualmachine"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/vlanconfig"
 	"github.com/harvester/terraform-provider-harvester/internal/provider/volume"
+	"github.com/harvester/terraform-provider-harvester/internal/provider/volumeattachment"
+	"github.com/harvester/terraform-provider-harvester/internal/provider/volumesnapshot"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
 	"github.com/harvester/terraform-provider-harvester/pkg/constants"
 )
 
@@ -47,12 +53,73 @@ func Provider() *schema.Provider {
 				Default:     "",
 				Description: "name of the kubernetes context to use",
 			},
+			constants.FieldProviderRancherURL: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "URL of the Rancher server the Harvester cluster is imported into, requests are sent through Rancher's `/k8s/clusters/<cluster_id>` proxy. Requires `rancher_token` and `cluster_id`.",
+			},
+			constants.FieldProviderRancherToken: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Rancher API token used to authenticate against `rancher_url`",
+			},
+			constants.FieldProviderClusterID: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "ID of the Harvester cluster in Rancher, e.g. `c-m-abcd1234`",
+			},
+			constants.FieldProviderRancherCACertificate: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "PEM encoded CA certificate used to verify the Rancher server certificate",
+			},
+			constants.FieldProviderRancherInsecure: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "skip the TLS verification of the Rancher server certificate",
+			},
+			constants.FieldProviderDefaultTags: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "tags added to all resources, the `tags` of a resource take precedence. The defaults are not shown in the `tags` of the resources, but in their `tags_all`.",
+			},
+			constants.FieldProviderDefaultLabels: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "labels added to all resources, the `labels` of a resource take precedence. The defaults are not shown in the `labels` of the resources, but in their `labels_all`.",
+			},
+			constants.FieldProviderDefaultNamespace: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: util.IsValidName,
+				Description:  "namespace of the namespaced resources and data sources which don't set one, defaults to `default`",
+			},
+			constants.FieldProviderReadOnly: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "fail any create, update or delete before it reaches the Harvester API, data sources and refresh keep working",
+			},
+			constants.FieldProviderAllowedNamespaces: {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: util.IsValidName,
+				},
+				Description: "namespaces the namespaced resources are allowed to be created, updated or deleted in, plans touching other namespaces fail. All namespaces are allowed when this is not set.",
+			},
 		},
-		DataSourcesMap: map[string]*schema.Resource{
+		DataSourcesMap: wrapDataSources(map[string]*schema.Resource{
 			constants.ResourceTypeCloudInitSecret:    cloudinitsecret.DataSourceCloudInitSecret(),
 			constants.ResourceTypeClusterNetwork:     clusternetwork.DataSourceClusterNetwork(),
 			constants.ResourceTypeIPPool:             ippool.DataSourceIPPool(),
 			constants.ResourceTypeImage:              image.DataSourceImage(),
+			constants.ResourceTypeImages:             image.DataSourceImages(),
 			constants.ResourceTypeKeyPair:            keypair.DataSourceKeypair(),
 			constants.ResourceTypeLoadBalancer:       loadbalancer.DataSourceLoadBalancer(),
 			constants.ResourceTypeNetwork:            network.DataSourceNetwork(),
@@ -64,25 +131,31 @@ func Provider() *schema.Provider {
 			constants.ResourceTypeVLANConfig:         vlanconfig.DataSourceVLANConfig(),
 			constants.ResourceTypeVirtualMachine:     virtualmachine.DataSourceVirtualMachine(),
 			constants.ResourceTypeVolume:             volume.DataSourceVolume(),
-		},
-		ResourcesMap: map[string]*schema.Resource{
-			constants.ResourceTypeBootstrap:          bootstrap.ResourceBootstrap(),
-			constants.ResourceTypeCloudInitSecret:    cloudinitsecret.ResourceCloudInitSecret(),
-			constants.ResourceTypeClusterNetwork:     clusternetwork.ResourceClusterNetwork(),
-			constants.ResourceTypeIPPool:             ippool.ResourceIPPool(),
-			constants.ResourceTypeImage:              image.ResourceImage(),
-			constants.ResourceTypeKeyPair:            keypair.ResourceKeypair(),
-			constants.ResourceTypeLoadBalancer:       loadbalancer.ResourceLoadBalancer(),
-			constants.ResourceTypeNetwork:            network.ResourceNetwork(),
-			constants.ResourceTypePCIDevice:          pcidevice.ResourcePCIDevice(),
-			constants.ResourceTypeSRIOVNetworkDevice: sriovdevice.ResourceSRIOVNetworkDevice(),
-			constants.ResourceTypeScheduleBackup:     schedulebackup.ResourceScheduleBackup(),
-			constants.ResourceTypeSetting:            setting.ResourceSetting(),
-			constants.ResourceTypeStorageClass:       storageclass.ResourceStorageClass(),
-			constants.ResourceTypeVLANConfig:         vlanconfig.ResourceVLANConfig(),
-			constants.ResourceTypeVirtualMachine:     virtualmachine.ResourceVirtualMachine(),
-			constants.ResourceTypeVolume:             volume.ResourceVolume(),
-		},
+			constants.ResourceTypeVolumes:            volume.DataSourceVolumes(),
+			constants.ResourceTypeVolumeSnapshot:     volumesnapshot.DataSourceVolumeSnapshot(),
+		}),
+		ResourcesMap: wrapResources(map[string]*schema.Resource{
+			constants.ResourceTypeAPIToken:             apitoken.ResourceAPIToken(),
+			constants.ResourceTypeBootstrap:            bootstrap.ResourceBootstrap(),
+			constants.ResourceTypeCloudInitSecret:      cloudinitsecret.ResourceCloudInitSecret(),
+			constants.ResourceTypeClusterNetwork:       clusternetwork.ResourceClusterNetwork(),
+			constants.ResourceTypeIPPool:               ippool.ResourceIPPool(),
+			constants.ResourceTypeImage:                image.ResourceImage(),
+			constants.ResourceTypeKeyPair:              keypair.ResourceKeypair(),
+			constants.ResourceTypeLoadBalancer:         loadbalancer.ResourceLoadBalancer(),
+			constants.ResourceTypeLonghornRecurringJob: longhornrecurringjob.ResourceLonghornRecurringJob(),
+			constants.ResourceTypeNetwork:              network.ResourceNetwork(),
+			constants.ResourceTypePCIDevice:            pcidevice.ResourcePCIDevice(),
+			constants.ResourceTypeSRIOVNetworkDevice:   sriovdevice.ResourceSRIOVNetworkDevice(),
+			constants.ResourceTypeScheduleBackup:       schedulebackup.ResourceScheduleBackup(),
+			constants.ResourceTypeSetting:              setting.ResourceSetting(),
+			constants.ResourceTypeStorageClass:         storageclass.ResourceStorageClass(),
+			constants.ResourceTypeVLANConfig:           vlanconfig.ResourceVLANConfig(),
+			constants.ResourceTypeVirtualMachine:       virtualmachine.ResourceVirtualMachine(),
+			constants.ResourceTypeVolume:               volume.ResourceVolume(),
+			constants.ResourceTypeVolumeAttachment:     volumeattachment.ResourceVolumeAttachment(),
+			constants.ResourceTypeVolumeSnapshot:       volumesnapshot.ResourceVolumeSnapshot(),
+		}),
 		ConfigureContextFunc: providerConfig,
 	}
 	return p
@@ -92,6 +165,9 @@ func providerConfig(ctx context.Context, d *schema.ResourceData) (interface{}, d
 	bootstrap := d.Get(constants.FieldProviderBootstrap).(bool)
 	kubeConfig := d.Get(constants.FieldProviderKubeConfig).(string)
 	kubeContext := d.Get(constants.FieldProviderKubeContext).(string)
+	rancherURL := d.Get(constants.FieldProviderRancherURL).(string)
+	rancherToken := d.Get(constants.FieldProviderRancherToken).(string)
+	clusterID := d.Get(constants.FieldProviderClusterID).(string)
 	if bootstrap {
 		if kubeConfig != "" {
 			return nil, diag.Errorf("kubeconfig is not allowed when bootstrap is true")
@@ -101,9 +177,36 @@ func providerConfig(ctx context.Context, d *schema.ResourceData) (interface{}, d
 			return nil, diag.Errorf("kubecontext is not allowed when bootstrap is true")
 		}
 
-		return &config.Config{
-			Bootstrap: bootstrap,
-		}, nil
+		if rancherURL != "" {
+			return nil, diag.Errorf("rancher_url is not allowed when bootstrap is true")
+		}
+
+		c := providerDefaults(d)
+		c.Bootstrap = bootstrap
+		return c, nil
+	}
+
+	if rancherURL != "" || rancherToken != "" || clusterID != "" {
+		if kubeConfig != "" || kubeContext != "" {
+			return nil, diag.Errorf("kubeconfig and kubecontext are not allowed when rancher_url is set")
+		}
+		if rancherURL == "" || rancherToken == "" || clusterID == "" {
+			return nil, diag.Errorf("rancher_url, rancher_token and cluster_id must be set together")
+		}
+		if _, err := url.ParseRequestURI(rancherURL); err != nil {
+			return nil, diag.Errorf("invalid rancher_url %q: %v", rancherURL, err)
+		}
+
+		c := providerDefaults(d)
+		c.RancherURL = rancherURL
+		c.RancherToken = rancherToken
+		c.ClusterID = clusterID
+		c.RancherCACertificate = d.Get(constants.FieldProviderRancherCACertificate).(string)
+		c.RancherInsecure = d.Get(constants.FieldProviderRancherInsecure).(bool)
+		if err := c.CheckVersion(ctx); err != nil {
+			return nil, diag.FromErr(err)
+		}
+		return c, nil
 	}
 
 	kubeConfig, err := homedir.Expand(d.Get(constants.FieldProviderKubeConfig).(string))
@@ -111,8 +214,25 @@ func providerConfig(ctx context.Context, d *schema.ResourceData) (interface{}, d
 		return nil, diag.FromErr(err)
 	}
 
+	c := providerDefaults(d)
+	c.KubeConfig = kubeConfig
+	c.KubeContext = kubeContext
+	if err := c.CheckVersion(ctx); err != nil {
+		return nil, diag.FromErr(err)
+	}
+	return c, nil
+}
+
+func providerDefaults(d *schema.ResourceData) *config.Config {
+	var allowedNamespaces []string
+	for _, namespace := range d.Get(constants.FieldProviderAllowedNamespaces).([]interface{}) {
+		allowedNamespaces = append(allowedNamespaces, namespace.(string))
+	}
 	return &config.Config{
-		KubeConfig:  kubeConfig,
-		KubeContext: kubeContext,
-	}, nil
+		DefaultTags:       util.MapMerge(nil, "", d.Get(constants.FieldProviderDefaultTags).(map[string]interface{})),
+		DefaultLabels:     util.MapMerge(nil, "", d.Get(constants.FieldProviderDefaultLabels).(map[string]interface{})),
+		DefaultNamespace:  d.Get(constants.FieldProviderDefaultNamespace).(string),
+		ReadOnly:          d.Get(constants.FieldProviderReadOnly).(bool),
+		AllowedNamespaces: allowedNamespaces,
+	}
 }
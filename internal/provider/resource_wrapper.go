@@ -0,0 +1,363 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+)
+
+// wrapResources applies the provider level settings, which are shared by
+// all resources, around the CRUD functions of each resource.
+func wrapResources(resources map[string]*schema.Resource) map[string]*schema.Resource {
+	for resourceType, r := range resources {
+		defaultNamespace := useProviderDefaultNamespace(r)
+		defaultFields := addDefaultTagsAndLabelsSchema(r)
+		if r.CreateContext != nil {
+			r.CreateContext = withWriteGuard(r, withDefaultTagsAndLabels(defaultFields, true, r.CreateContext))
+			if defaultNamespace {
+				r.CreateContext = withDefaultNamespace(r.CreateContext)
+			}
+		}
+		if r.ReadContext != nil {
+			r.ReadContext = withDefaultTagsAndLabels(defaultFields, false, r.ReadContext)
+			if defaultNamespace {
+				r.ReadContext = withDefaultNamespace(r.ReadContext)
+			}
+		}
+		if r.UpdateContext != nil {
+			r.UpdateContext = withWriteGuard(r, withDefaultTagsAndLabels(defaultFields, true, r.UpdateContext))
+		}
+		if r.DeleteContext != nil {
+			r.DeleteContext = withWriteGuard(r, r.DeleteContext)
+		}
+		customizeDiffs := []schema.CustomizeDiffFunc{}
+		if defaultNamespace {
+			customizeDiffs = append(customizeDiffs, defaultNamespaceCustomizeDiff)
+		}
+		if len(defaultFields) > 0 {
+			customizeDiffs = append(customizeDiffs, defaultTagsAndLabelsCustomizeDiff(defaultFields))
+		}
+		if _, ok := r.Schema[constants.FieldCommonNamespace]; ok {
+			customizeDiffs = append(customizeDiffs, allowedNamespacesCustomizeDiff)
+		}
+		customizeDiffs = append(customizeDiffs, r.CustomizeDiff)
+		if _, ok := r.Schema[constants.FieldCommonDeletionProtection]; ok {
+			if r.DeleteContext != nil {
+				r.DeleteContext = withDeletionProtection(resourceType, r.DeleteContext)
+			}
+			customizeDiffs = append(customizeDiffs, deletionProtectionCustomizeDiff(resourceType, r))
+		}
+		r.CustomizeDiff = sequenceCustomizeDiff(customizeDiffs...)
+	}
+	return resources
+}
+
+func wrapDataSources(dataSources map[string]*schema.Resource) map[string]*schema.Resource {
+	for _, r := range dataSources {
+		if useProviderDefaultNamespace(r) && r.ReadContext != nil {
+			r.ReadContext = withDefaultNamespace(r.ReadContext)
+		}
+	}
+	return dataSources
+}
+
+func sequenceCustomizeDiff(funcs ...schema.CustomizeDiffFunc) schema.CustomizeDiffFunc {
+	customizeDiffs := make([]schema.CustomizeDiffFunc, 0, len(funcs))
+	for _, f := range funcs {
+		if f != nil {
+			customizeDiffs = append(customizeDiffs, f)
+		}
+	}
+	if len(customizeDiffs) == 0 {
+		return nil
+	}
+	return customdiff.Sequence(customizeDiffs...)
+}
+
+// useProviderDefaultNamespace replaces the static `default` namespace of the
+// schema by the provider default_namespace. The namespace becomes computed, it
+// is planned by defaultNamespaceCustomizeDiff and set by withDefaultNamespace
+// if it is still empty, e.g. in data sources or on import.
+func useProviderDefaultNamespace(r *schema.Resource) bool {
+	namespace, ok := r.Schema[constants.FieldCommonNamespace]
+	if !ok || !namespace.Optional || namespace.Default != constants.NamespaceDefault {
+		return false
+	}
+	namespace.Default = nil
+	namespace.Computed = true
+	namespace.Description = "Defaults to the provider `default_namespace`, or `default` when that is not set"
+	return true
+}
+
+// defaultNamespaceCustomizeDiff plans the provider default_namespace for new
+// resources which don't configure a namespace.
+func defaultNamespaceCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" {
+		return nil
+	}
+	if rawConfig := d.GetRawConfig(); !rawConfig.IsNull() {
+		if !rawConfig.GetAttr(constants.FieldCommonNamespace).IsNull() {
+			return nil
+		}
+	} else if d.Get(constants.FieldCommonNamespace).(string) != "" {
+		return nil
+	}
+	return d.SetNew(constants.FieldCommonNamespace, providerNamespace(meta))
+}
+
+func providerNamespace(meta interface{}) string {
+	if c, ok := meta.(*config.Config); ok {
+		return c.Namespace()
+	}
+	return constants.NamespaceDefault
+}
+
+// allowedNamespacesCustomizeDiff rejects plans of resources outside the
+// provider allowed_namespaces, it runs after the default namespace is planned.
+func allowedNamespacesCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	c, ok := meta.(*config.Config)
+	if !ok || !d.NewValueKnown(constants.FieldCommonNamespace) {
+		return nil
+	}
+	return c.CheckNamespaceAllowed(d.Get(constants.FieldCommonNamespace).(string))
+}
+
+// withWriteGuard fails create, update and delete before they reach the API if
+// the provider is read only or the resource is outside the allowed namespaces.
+func withWriteGuard(r *schema.Resource, f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		if c, ok := meta.(*config.Config); ok {
+			var namespace string
+			if _, ok := r.Schema[constants.FieldCommonNamespace]; ok {
+				namespace = d.Get(constants.FieldCommonNamespace).(string)
+			}
+			if err := c.CheckWritable(namespace); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+		return f(ctx, d, meta)
+	}
+}
+
+// withDeletionProtection refuses to delete resources whose state has
+// deletion_protection enabled.
+func withDeletionProtection(resourceType string, f schema.DeleteContextFunc) schema.DeleteContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		if d.Get(constants.FieldCommonDeletionProtection).(bool) {
+			return diag.Errorf("%s %s has %s enabled, set it to false and apply before deleting it", resourceType, d.Id(), constants.FieldCommonDeletionProtection)
+		}
+		return f(ctx, d, meta)
+	}
+}
+
+// deletionProtectionCustomizeDiff rejects plans which replace a resource whose
+// state has deletion_protection enabled, since the replacement deletes it. It
+// must run after the other CustomizeDiff functions of the resource, to see the
+// values they set.
+func deletionProtectionCustomizeDiff(resourceType string, r *schema.Resource) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if d.Id() == "" {
+			return nil
+		}
+		if protected, _ := d.GetChange(constants.FieldCommonDeletionProtection); !protected.(bool) {
+			return nil
+		}
+		keys := forceNewFields(r.Schema)
+		// nested fields can only be changed by the configuration
+		for _, key := range d.GetChangedKeysPrefix("") {
+			if forceNewKey(r.Schema, strings.Split(key, ".")) {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			if d.HasChange(key) {
+				return fmt.Errorf("%s %s has %s enabled, the change of %s requires replacing it", resourceType, d.Id(), constants.FieldCommonDeletionProtection, key)
+			}
+		}
+		return nil
+	}
+}
+
+// forceNewFields returns the top level ForceNew fields, sorted to report the
+// same field on every plan.
+func forceNewFields(s map[string]*schema.Schema) []string {
+	fields := []string{}
+	for key, field := range s {
+		if field.ForceNew {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// forceNewKey reports whether the flatmap key, e.g. disk.0.size, is or is
+// nested in a ForceNew field.
+func forceNewKey(s map[string]*schema.Schema, parts []string) bool {
+	if len(parts) == 0 {
+		return false
+	}
+	field, ok := s[parts[0]]
+	if !ok {
+		return false
+	}
+	if field.ForceNew {
+		return true
+	}
+	// skip the list index or set hash to get to the nested field
+	if elem, ok := field.Elem.(*schema.Resource); ok && len(parts) > 2 {
+		return forceNewKey(elem.Schema, parts[2:])
+	}
+	return false
+}
+
+// withDefaultNamespace sets the namespace if it is still empty, from the ID of
+// imported resources, or else from the provider default_namespace.
+func withDefaultNamespace(f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		if d.Get(constants.FieldCommonNamespace).(string) == "" {
+			namespace := providerNamespace(meta)
+			if d.Id() != "" {
+				if idNamespace, _, err := helper.IDParts(d.Id()); err == nil && idNamespace != "" {
+					namespace = idNamespace
+				}
+			}
+			if err := d.Set(constants.FieldCommonNamespace, namespace); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+		return f(ctx, d, meta)
+	}
+}
+
+// addDefaultTagsAndLabelsSchema adds tags_all and labels_all to resources
+// having tags and labels, it returns the fields which got added.
+func addDefaultTagsAndLabelsSchema(r *schema.Resource) map[string]string {
+	fields := map[string]string{}
+	for field, allField := range util.AllFields {
+		if _, ok := r.Schema[field]; !ok {
+			continue
+		}
+		r.Schema[allField] = &schema.Schema{
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: fmt.Sprintf("The %s of the resource merged with the provider `%s`", field, providerDefaultsField(field)),
+		}
+		fields[field] = allField
+	}
+	return fields
+}
+
+func providerDefaultsField(field string) string {
+	if field == constants.FieldCommonTags {
+		return constants.FieldProviderDefaultTags
+	}
+	return constants.FieldProviderDefaultLabels
+}
+
+func providerDefaults(meta interface{}, field string) map[string]string {
+	c, ok := meta.(*config.Config)
+	if !ok {
+		return nil
+	}
+	if field == constants.FieldCommonTags {
+		return c.DefaultTags
+	}
+	return c.DefaultLabels
+}
+
+// mergeDefaults returns the defaults overridden by the values.
+func mergeDefaults(defaults map[string]string, values map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(defaults)+len(values))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return merged
+}
+
+// stripDefaults returns all values except the defaults, unless the key is in
+// the configured values too.
+func stripDefaults(defaults map[string]string, all, configured map[string]interface{}) map[string]interface{} {
+	values := make(map[string]interface{}, len(all))
+	for key, value := range all {
+		_, isConfigured := configured[key]
+		if defaultValue, isDefault := defaults[key]; isDefault && !isConfigured && defaultValue == value {
+			continue
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// defaultTagsAndLabelsCustomizeDiff plans tags_all and labels_all, so adding,
+// changing or removing a provider default is applied to existing resources.
+func defaultTagsAndLabelsCustomizeDiff(fields map[string]string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		for field, allField := range fields {
+			if !d.NewValueKnown(field) {
+				if err := d.SetNewComputed(allField); err != nil {
+					return err
+				}
+				continue
+			}
+			merged := mergeDefaults(providerDefaults(meta, field), d.Get(field).(map[string]interface{}))
+			if err := d.SetNew(allField, merged); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// withDefaultTagsAndLabels sets tags_all and labels_all before create and
+// update, which the resource constructors use instead of tags and labels.
+// Afterwards the resource state holds all tags and labels of the object, they
+// are moved into tags_all and labels_all, and the provider defaults are
+// removed from tags and labels unless the resource sets the same key itself.
+func withDefaultTagsAndLabels(fields map[string]string, write bool, f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	if len(fields) == 0 {
+		return f
+	}
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		configured := map[string]map[string]interface{}{}
+		for field, allField := range fields {
+			configured[field] = d.Get(field).(map[string]interface{})
+			if !write {
+				continue
+			}
+			if err := d.Set(allField, mergeDefaults(providerDefaults(meta, field), configured[field])); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+
+		diags := f(ctx, d, meta)
+		if diags.HasError() || d.Id() == "" {
+			return diags
+		}
+
+		for field, allField := range fields {
+			all := d.Get(field).(map[string]interface{})
+			if err := d.Set(allField, all); err != nil {
+				return append(diags, diag.FromErr(err)...)
+			}
+			if err := d.Set(field, stripDefaults(providerDefaults(meta, field), all, configured[field])); err != nil {
+				return append(diags, diag.FromErr(err)...)
+			}
+		}
+		return diags
+	}
+}
@@ -0,0 +1,416 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func testNamespacedResource(system bool) *schema.Resource {
+	s := map[string]*schema.Schema{}
+	util.NamespacedSchemaWrap(s, system)
+	return &schema.Resource{Schema: s}
+}
+
+func TestUseProviderDefaultNamespace(t *testing.T) {
+	r := testNamespacedResource(false)
+	if !useProviderDefaultNamespace(r) {
+		t.Fatal("expected the default namespace to be replaced")
+	}
+	namespace := r.Schema[constants.FieldCommonNamespace]
+	if namespace.Default != nil || !namespace.Computed || !namespace.Optional {
+		t.Errorf("expected an optional computed namespace without default, got default=%v computed=%v optional=%v", namespace.Default, namespace.Computed, namespace.Optional)
+	}
+	if err := schema.InternalMap(r.Schema).InternalValidate(nil); err != nil {
+		t.Errorf("unexpected InternalValidate error: %v", err)
+	}
+
+	system := testNamespacedResource(true)
+	if useProviderDefaultNamespace(system) {
+		t.Error("expected the harvester-system namespace to be kept")
+	}
+	if system.Schema[constants.FieldCommonNamespace].Default != constants.NamespaceHarvesterSystem {
+		t.Errorf("expected the default %s, got %v", constants.NamespaceHarvesterSystem, system.Schema[constants.FieldCommonNamespace].Default)
+	}
+
+	nonNamespaced := &schema.Resource{Schema: map[string]*schema.Schema{}}
+	util.NonNamespacedSchemaWrap(nonNamespaced.Schema)
+	if useProviderDefaultNamespace(nonNamespaced) {
+		t.Error("expected resources without namespace to be kept")
+	}
+}
+
+func TestWithDefaultNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		raw       map[string]interface{}
+		meta      interface{}
+		namespace string
+	}{
+		{
+			name:      "provider default namespace",
+			raw:       map[string]interface{}{constants.FieldCommonName: "foo"},
+			meta:      &config.Config{DefaultNamespace: "team-a"},
+			namespace: "team-a",
+		},
+		{
+			name:      "default namespace without provider default",
+			raw:       map[string]interface{}{constants.FieldCommonName: "foo"},
+			meta:      &config.Config{},
+			namespace: constants.NamespaceDefault,
+		},
+		{
+			name:      "configured namespace",
+			raw:       map[string]interface{}{constants.FieldCommonName: "foo", constants.FieldCommonNamespace: "team-b"},
+			meta:      &config.Config{DefaultNamespace: "team-a"},
+			namespace: "team-b",
+		},
+		{
+			name:      "namespace of an imported resource",
+			id:        "team-c/foo",
+			raw:       map[string]interface{}{},
+			meta:      &config.Config{DefaultNamespace: "team-a"},
+			namespace: "team-c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testNamespacedResource(false)
+			useProviderDefaultNamespace(r)
+			d := schema.TestResourceDataRaw(t, r.Schema, tt.raw)
+			d.SetId(tt.id)
+
+			var namespace string
+			diags := withDefaultNamespace(func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+				namespace = d.Get(constants.FieldCommonNamespace).(string)
+				return nil
+			})(context.Background(), d, tt.meta)
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if namespace != tt.namespace {
+				t.Errorf("expected namespace %q, got %q", tt.namespace, namespace)
+			}
+		})
+	}
+}
+
+func TestMergeDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults map[string]string
+		values   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "no defaults",
+			values:   map[string]interface{}{"a": "1"},
+			expected: map[string]interface{}{"a": "1"},
+		},
+		{
+			name:     "defaults only",
+			defaults: map[string]string{"team": "a"},
+			expected: map[string]interface{}{"team": "a"},
+		},
+		{
+			name:     "values take precedence",
+			defaults: map[string]string{"team": "a", "env": "dev"},
+			values:   map[string]interface{}{"team": "b"},
+			expected: map[string]interface{}{"team": "b", "env": "dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if merged := mergeDefaults(tt.defaults, tt.values); !reflect.DeepEqual(merged, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, merged)
+			}
+		})
+	}
+}
+
+func TestStripDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		defaults   map[string]string
+		all        map[string]interface{}
+		configured map[string]interface{}
+		expected   map[string]interface{}
+	}{
+		{
+			name:     "defaults are removed",
+			defaults: map[string]string{"team": "a"},
+			all:      map[string]interface{}{"team": "a", "app": "web"},
+			expected: map[string]interface{}{"app": "web"},
+		},
+		{
+			name:     "empty default values are removed",
+			defaults: map[string]string{"team": ""},
+			all:      map[string]interface{}{"team": ""},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "changed defaults are kept",
+			defaults: map[string]string{"team": "a"},
+			all:      map[string]interface{}{"team": "b"},
+			expected: map[string]interface{}{"team": "b"},
+		},
+		{
+			name:       "configured defaults are kept",
+			defaults:   map[string]string{"team": "a"},
+			all:        map[string]interface{}{"team": "a"},
+			configured: map[string]interface{}{"team": "a"},
+			expected:   map[string]interface{}{"team": "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if values := stripDefaults(tt.defaults, tt.all, tt.configured); !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, values)
+			}
+		})
+	}
+}
+
+func TestAddDefaultTagsAndLabelsSchema(t *testing.T) {
+	r := testNamespacedResource(false)
+	fields := addDefaultTagsAndLabelsSchema(r)
+	if !reflect.DeepEqual(fields, util.AllFields) {
+		t.Errorf("expected %v, got %v", util.AllFields, fields)
+	}
+	for _, allField := range util.AllFields {
+		if s, ok := r.Schema[allField]; !ok || !s.Computed || s.Optional {
+			t.Errorf("expected %s to be a computed field", allField)
+		}
+	}
+
+	withoutTags := &schema.Resource{Schema: map[string]*schema.Schema{
+		constants.FieldCommonName: {Type: schema.TypeString, Required: true},
+	}}
+	if fields := addDefaultTagsAndLabelsSchema(withoutTags); len(fields) != 0 {
+		t.Errorf("expected no fields, got %v", fields)
+	}
+}
+
+func TestWithDefaultTagsAndLabels(t *testing.T) {
+	meta := &config.Config{
+		DefaultTags:   map[string]string{"team": "a", "env": "dev"},
+		DefaultLabels: map[string]string{"owner": "ops"},
+	}
+	tests := []struct {
+		name      string
+		write     bool
+		raw       map[string]interface{}
+		object    map[string]map[string]interface{}
+		expected  map[string]map[string]interface{}
+		objectAll map[string]map[string]interface{}
+	}{
+		{
+			name:  "create merges the defaults",
+			write: true,
+			raw: map[string]interface{}{
+				constants.FieldCommonName: "foo",
+				constants.FieldCommonTags: map[string]interface{}{"env": "prod", "app": "web"},
+			},
+			expected: map[string]map[string]interface{}{
+				constants.FieldCommonTags:   {"env": "prod", "app": "web"},
+				constants.FieldCommonLabels: {},
+			},
+			objectAll: map[string]map[string]interface{}{
+				constants.FieldCommonTags:   {"team": "a", "env": "prod", "app": "web"},
+				constants.FieldCommonLabels: {"owner": "ops"},
+			},
+		},
+		{
+			name: "read keeps the configured defaults",
+			raw: map[string]interface{}{
+				constants.FieldCommonName:   "foo",
+				constants.FieldCommonLabels: map[string]interface{}{"owner": "ops"},
+			},
+			object: map[string]map[string]interface{}{
+				constants.FieldCommonTags:   {"team": "a", "env": "dev"},
+				constants.FieldCommonLabels: {"owner": "ops", "extra": "x"},
+			},
+			expected: map[string]map[string]interface{}{
+				constants.FieldCommonTags:   {},
+				constants.FieldCommonLabels: {"owner": "ops", "extra": "x"},
+			},
+			objectAll: map[string]map[string]interface{}{
+				constants.FieldCommonTags:   {"team": "a", "env": "dev"},
+				constants.FieldCommonLabels: {"owner": "ops", "extra": "x"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testNamespacedResource(false)
+			fields := addDefaultTagsAndLabelsSchema(r)
+			d := schema.TestResourceDataRaw(t, r.Schema, tt.raw)
+			if !tt.write {
+				d.SetId("default/foo")
+			}
+
+			diags := withDefaultTagsAndLabels(fields, tt.write, func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+				// the resource writes tags_all and labels_all and reads back all of them
+				for field, allField := range fields {
+					all := d.Get(allField).(map[string]interface{})
+					if tt.object != nil {
+						all = tt.object[field]
+					}
+					if err := d.Set(field, all); err != nil {
+						return diag.FromErr(err)
+					}
+				}
+				d.SetId("default/foo")
+				return nil
+			})(context.Background(), d, meta)
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			for field, allField := range fields {
+				if values := d.Get(field).(map[string]interface{}); !reflect.DeepEqual(values, tt.expected[field]) {
+					t.Errorf("expected %s %v, got %v", field, tt.expected[field], values)
+				}
+				if values := d.Get(allField).(map[string]interface{}); !reflect.DeepEqual(values, tt.objectAll[field]) {
+					t.Errorf("expected %s %v, got %v", allField, tt.objectAll[field], values)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceCustomizeDiff(t *testing.T) {
+	if f := sequenceCustomizeDiff(nil, nil); f != nil {
+		t.Error("expected no CustomizeDiff without functions")
+	}
+	if f := sequenceCustomizeDiff(nil, defaultNamespaceCustomizeDiff); f == nil {
+		t.Error("expected a CustomizeDiff")
+	}
+}
+
+func TestWithWriteGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		meta      *config.Config
+		namespace string
+		called    bool
+	}{
+		{
+			name:      "writable",
+			meta:      &config.Config{},
+			namespace: "default",
+			called:    true,
+		},
+		{
+			name:      "read only",
+			meta:      &config.Config{ReadOnly: true},
+			namespace: "default",
+		},
+		{
+			name:      "allowed namespace",
+			meta:      &config.Config{AllowedNamespaces: []string{"team-a"}},
+			namespace: "team-a",
+			called:    true,
+		},
+		{
+			name:      "namespace not allowed",
+			meta:      &config.Config{AllowedNamespaces: []string{"team-a"}},
+			namespace: "team-b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testNamespacedResource(false)
+			d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+				constants.FieldCommonName:      "foo",
+				constants.FieldCommonNamespace: tt.namespace,
+			})
+
+			called := false
+			diags := withWriteGuard(r, func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+				called = true
+				return nil
+			})(context.Background(), d, tt.meta)
+			if called != tt.called {
+				t.Errorf("expected called=%v, got %v", tt.called, called)
+			}
+			if diags.HasError() == tt.called {
+				t.Errorf("expected error=%v, got %v", !tt.called, diags)
+			}
+		})
+	}
+}
+
+func testForceNewSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {Type: schema.TypeString, Required: true, ForceNew: true},
+		"size": {Type: schema.TypeString, Optional: true},
+		"disk": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"image": {Type: schema.TypeString, Optional: true, ForceNew: true},
+					"size":  {Type: schema.TypeString, Optional: true},
+				},
+			},
+		},
+	}
+}
+
+func TestForceNewKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		forceNew bool
+	}{
+		{key: "name", forceNew: true},
+		{key: "size"},
+		{key: "disk.#"},
+		{key: "disk.0.image", forceNew: true},
+		{key: "disk.0.size"},
+		{key: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if forceNew := forceNewKey(testForceNewSchema(), strings.Split(tt.key, ".")); forceNew != tt.forceNew {
+				t.Errorf("expected %v, got %v", tt.forceNew, forceNew)
+			}
+		})
+	}
+}
+
+func TestForceNewFields(t *testing.T) {
+	if fields := forceNewFields(testForceNewSchema()); !reflect.DeepEqual(fields, []string{"name"}) {
+		t.Errorf("expected [name], got %v", fields)
+	}
+}
+
+func TestWithDeletionProtection(t *testing.T) {
+	for _, protected := range []bool{true, false} {
+		s := map[string]*schema.Schema{}
+		util.NonNamespacedSchemaWrap(s)
+		util.DeletionProtectionSchemaWrap(s)
+		d := schema.TestResourceDataRaw(t, s, map[string]interface{}{
+			constants.FieldCommonName:               "foo",
+			constants.FieldCommonDeletionProtection: protected,
+		})
+		d.SetId("foo")
+
+		deleted := false
+		diags := withDeletionProtection(constants.ResourceTypeVolume, func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			deleted = true
+			return nil
+		})(context.Background(), d, &config.Config{})
+		if deleted == protected || diags.HasError() != protected {
+			t.Errorf("deletion_protection=%v: expected deleted=%v, got deleted=%v and %v", protected, !protected, deleted, diags)
+		}
+	}
+}
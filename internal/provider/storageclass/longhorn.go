@@ -0,0 +1,168 @@
+package storageclass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+type longhornRecurringJobSelector struct {
+	Name    string `json:"name"`
+	IsGroup bool   `json:"isGroup"`
+}
+
+// expandLonghornParameters returns the storage class parameters of the
+// longhorn block.
+func expandLonghornParameters(r map[string]interface{}) (map[string]string, error) {
+	parameters := map[string]string{
+		constants.LonghornParameterMigratable: strconv.FormatBool(r[constants.FieldLonghornMigratable].(bool)),
+	}
+	if numberOfReplicas := r[constants.FieldLonghornNumberOfReplicas].(int); numberOfReplicas > 0 {
+		parameters[constants.LonghornParameterNumberOfReplicas] = strconv.Itoa(numberOfReplicas)
+	}
+	if staleReplicaTimeout := r[constants.FieldLonghornStaleReplicaTimeout].(int); staleReplicaTimeout > 0 {
+		parameters[constants.LonghornParameterStaleReplicaTimeout] = strconv.Itoa(staleReplicaTimeout)
+	}
+	if diskSelector := toStrings(r[constants.FieldLonghornDiskSelector].([]interface{})); len(diskSelector) > 0 {
+		parameters[constants.LonghornParameterDiskSelector] = strings.Join(diskSelector, ",")
+	}
+	if nodeSelector := toStrings(r[constants.FieldLonghornNodeSelector].([]interface{})); len(nodeSelector) > 0 {
+		parameters[constants.LonghornParameterNodeSelector] = strings.Join(nodeSelector, ",")
+	}
+	if dataLocality := r[constants.FieldLonghornDataLocality].(string); dataLocality != "" {
+		parameters[constants.LonghornParameterDataLocality] = dataLocality
+	}
+	if r[constants.FieldLonghornEncrypted].(bool) {
+		secretName := r[constants.FieldLonghornEncryptionSecretName].(string)
+		secretNamespace := r[constants.FieldLonghornEncryptionSecretNamespace].(string)
+		parameters[constants.LonghornParameterEncrypted] = "true"
+		parameters[constants.LonghornParameterProvisionerSecretName] = secretName
+		parameters[constants.LonghornParameterProvisionerSecretNamespace] = secretNamespace
+		parameters[constants.LonghornParameterNodePublishSecretName] = secretName
+		parameters[constants.LonghornParameterNodePublishSecretNamespace] = secretNamespace
+		parameters[constants.LonghornParameterNodeStageSecretName] = secretName
+		parameters[constants.LonghornParameterNodeStageSecretNamespace] = secretNamespace
+	}
+	if selectors := r[constants.FieldLonghornRecurringJobSelector].([]interface{}); len(selectors) > 0 {
+		recurringJobSelector := make([]longhornRecurringJobSelector, 0, len(selectors))
+		for _, selector := range selectors {
+			s := selector.(map[string]interface{})
+			recurringJobSelector = append(recurringJobSelector, longhornRecurringJobSelector{
+				Name:    s[constants.FieldLonghornRecurringJobSelectorName].(string),
+				IsGroup: s[constants.FieldLonghornRecurringJobSelectorIsGroup].(bool),
+			})
+		}
+		value, err := json.Marshal(recurringJobSelector)
+		if err != nil {
+			return nil, err
+		}
+		parameters[constants.LonghornParameterRecurringJobSelector] = string(value)
+	}
+	if backingImages := r[constants.FieldLonghornBackingImage].([]interface{}); len(backingImages) > 0 && backingImages[0] != nil {
+		backingImage := backingImages[0].(map[string]interface{})
+		parameters[constants.LonghornParameterBackingImage] = backingImage[constants.FieldLonghornBackingImageName].(string)
+		if dataSourceType := backingImage[constants.FieldLonghornBackingImageDataSourceType].(string); dataSourceType != "" {
+			parameters[constants.LonghornParameterBackingImageDataSourceType] = dataSourceType
+		}
+		if dataSourceParameters := backingImage[constants.FieldLonghornBackingImageDataSourceParameters].(map[string]interface{}); len(dataSourceParameters) > 0 {
+			value, err := json.Marshal(dataSourceParameters)
+			if err != nil {
+				return nil, err
+			}
+			parameters[constants.LonghornParameterBackingImageDataSourceParameters] = string(value)
+		}
+		if checksum := backingImage[constants.FieldLonghornBackingImageChecksum].(string); checksum != "" {
+			parameters[constants.LonghornParameterBackingImageChecksum] = checksum
+		}
+	}
+	return parameters, nil
+}
+
+// longhornParameterKeys returns the sorted keys of the parameters which are
+// set by the longhorn block.
+func longhornParameterKeys(parameters map[string]string) []string {
+	keys := make([]string, 0, len(parameters))
+	for key := range parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func resourceStorageClassCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	longhorn := d.Get(constants.FieldStorageClassLonghorn).([]interface{})
+	if len(longhorn) == 0 || longhorn[0] == nil {
+		return nil
+	}
+	if provisioner := d.Get(constants.FieldStorageClassVolumeProvisioner).(string); d.NewValueKnown(constants.FieldStorageClassVolumeProvisioner) && provisioner != LonghornDriverName {
+		return fmt.Errorf("%s can only be set when %s is %q", constants.FieldStorageClassLonghorn, constants.FieldStorageClassVolumeProvisioner, LonghornDriverName)
+	}
+	r := longhorn[0].(map[string]interface{})
+
+	for _, key := range []string{constants.FieldLonghornDiskSelector, constants.FieldLonghornNodeSelector} {
+		for _, tag := range r[key].([]interface{}) {
+			if tag, ok := tag.(string); ok && (strings.TrimSpace(tag) == "" || strings.Contains(tag, ",")) {
+				return fmt.Errorf("%s.0.%s: tags can't be empty or contain commas, got %q", constants.FieldStorageClassLonghorn, key, tag)
+			}
+		}
+	}
+
+	encrypted := r[constants.FieldLonghornEncrypted].(bool)
+	for _, key := range []string{constants.FieldLonghornEncryptionSecretName, constants.FieldLonghornEncryptionSecretNamespace} {
+		if !d.NewValueKnown(constants.FieldStorageClassLonghorn + ".0." + key) {
+			continue
+		}
+		if encrypted && r[key].(string) == "" {
+			return fmt.Errorf("%s.0.%s must be set when %s is true", constants.FieldStorageClassLonghorn, key, constants.FieldLonghornEncrypted)
+		}
+		if !encrypted && r[key].(string) != "" {
+			return fmt.Errorf("%s.0.%s can only be set when %s is true", constants.FieldStorageClassLonghorn, key, constants.FieldLonghornEncrypted)
+		}
+	}
+
+	if backingImages := r[constants.FieldLonghornBackingImage].([]interface{}); len(backingImages) > 0 && backingImages[0] != nil {
+		backingImage := backingImages[0].(map[string]interface{})
+		dataSourceType := backingImage[constants.FieldLonghornBackingImageDataSourceType].(string)
+		dataSourceParameters := backingImage[constants.FieldLonghornBackingImageDataSourceParameters].(map[string]interface{})
+		if len(dataSourceParameters) > 0 && dataSourceType == "" {
+			return fmt.Errorf("%s.0.%s.0.%s must be set with %s", constants.FieldStorageClassLonghorn, constants.FieldLonghornBackingImage,
+				constants.FieldLonghornBackingImageDataSourceType, constants.FieldLonghornBackingImageDataSourceParameters)
+		}
+		if dataSourceType == constants.LonghornBackingImageDataSourceTypeDownload && dataSourceParameters["url"] == nil {
+			return fmt.Errorf("%s.0.%s.0.%s must have a url when %s is %q", constants.FieldStorageClassLonghorn, constants.FieldLonghornBackingImage,
+				constants.FieldLonghornBackingImageDataSourceParameters, constants.FieldLonghornBackingImageDataSourceType, dataSourceType)
+		}
+	}
+
+	// the parameters of the longhorn block can't be set twice
+	if !d.NewValueKnown(constants.FieldStorageClassParameters) {
+		return nil
+	}
+	longhornParameters, err := expandLonghornParameters(r)
+	if err != nil {
+		return err
+	}
+	for key := range d.Get(constants.FieldStorageClassParameters).(map[string]interface{}) {
+		if _, ok := longhornParameters[key]; ok {
+			return fmt.Errorf("parameter %q is set by %s and can't be set in %s", key, constants.FieldStorageClassLonghorn, constants.FieldStorageClassParameters)
+		}
+	}
+	return nil
+}
+
+func toStrings(values []interface{}) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value, ok := value.(string); ok && value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
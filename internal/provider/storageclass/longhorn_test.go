@@ -0,0 +1,154 @@
+package storageclass
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/importer"
+)
+
+// TestLonghornParametersRoundTrip expands the longhorn block into parameters,
+// imports the storage class again and expects the same block.
+func TestLonghornParametersRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		longhorn map[string]interface{}
+	}{
+		{
+			name: "defaults",
+			longhorn: map[string]interface{}{
+				constants.FieldLonghornMigratable: true,
+			},
+		},
+		{
+			name: "replicas and selectors",
+			longhorn: map[string]interface{}{
+				constants.FieldLonghornMigratable:          false,
+				constants.FieldLonghornNumberOfReplicas:    2,
+				constants.FieldLonghornStaleReplicaTimeout: 30,
+				constants.FieldLonghornDiskSelector:        []interface{}{"ssd", "nvme"},
+				constants.FieldLonghornNodeSelector:        []interface{}{"storage"},
+				constants.FieldLonghornDataLocality:        "best-effort",
+			},
+		},
+		{
+			name: "encryption and recurring jobs",
+			longhorn: map[string]interface{}{
+				constants.FieldLonghornMigratable:                true,
+				constants.FieldLonghornEncrypted:                 true,
+				constants.FieldLonghornEncryptionSecretName:      "encryption",
+				constants.FieldLonghornEncryptionSecretNamespace: "longhorn-system",
+				constants.FieldLonghornRecurringJobSelector: []interface{}{
+					map[string]interface{}{
+						constants.FieldLonghornRecurringJobSelectorName:    "daily",
+						constants.FieldLonghornRecurringJobSelectorIsGroup: true,
+					},
+					map[string]interface{}{
+						constants.FieldLonghornRecurringJobSelectorName: "weekly-backup",
+					},
+				},
+			},
+		},
+		{
+			name: "backing image",
+			longhorn: map[string]interface{}{
+				constants.FieldLonghornMigratable: true,
+				constants.FieldLonghornBackingImage: []interface{}{
+					map[string]interface{}{
+						constants.FieldLonghornBackingImageName:           "leap",
+						constants.FieldLonghornBackingImageDataSourceType: constants.LonghornBackingImageDataSourceTypeDownload,
+						constants.FieldLonghornBackingImageDataSourceParameters: map[string]interface{}{
+							"url": "https://example.com/leap.qcow2",
+						},
+						constants.FieldLonghornBackingImageChecksum: "abc",
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, Schema(), map[string]interface{}{
+				constants.FieldStorageClassLonghorn: []interface{}{tc.longhorn},
+			})
+			longhorn := d.Get(constants.FieldStorageClassLonghorn).([]interface{})
+			parameters, err := expandLonghornParameters(longhorn[0].(map[string]interface{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			storageClass := newTestStorageClass(parameters)
+			stateGetter, err := importer.ResourceStorageClassStateGetter(storageClass)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expectation := map[string]string{"other": "value"}; !reflect.DeepEqual(stateGetter.States[constants.FieldStorageClassParameters], expectation) {
+				t.Errorf("expected the other parameters %v, got %v", expectation, stateGetter.States[constants.FieldStorageClassParameters])
+			}
+
+			imported := schema.TestResourceDataRaw(t, Schema(), map[string]interface{}{})
+			if err = imported.Set(constants.FieldStorageClassLonghorn, stateGetter.States[constants.FieldStorageClassLonghorn]); err != nil {
+				t.Fatal(err)
+			}
+			if actual := imported.Get(constants.FieldStorageClassLonghorn); !reflect.DeepEqual(actual, longhorn) {
+				t.Errorf("expected the longhorn block %v, got %v", longhorn, actual)
+			}
+			importedParameters, err := expandLonghornParameters(imported.Get(constants.FieldStorageClassLonghorn).([]interface{})[0].(map[string]interface{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(importedParameters, parameters) {
+				t.Errorf("expected the parameters %v, got %v", parameters, importedParameters)
+			}
+		})
+	}
+}
+
+// TestLonghornParametersWithoutBlock expects all parameters of a storage class
+// which isn't created with the longhorn block in parameters.
+func TestLonghornParametersWithoutBlock(t *testing.T) {
+	storageClass := newTestStorageClass(map[string]string{constants.LonghornParameterNumberOfReplicas: "3"})
+	delete(storageClass.Annotations, constants.AnnotationStorageClassLonghornParameters)
+	stateGetter, err := importer.ResourceStorageClassStateGetter(storageClass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stateGetter.States[constants.FieldStorageClassParameters], storageClass.Parameters) {
+		t.Errorf("expected the parameters %v, got %v", storageClass.Parameters, stateGetter.States[constants.FieldStorageClassParameters])
+	}
+	if longhorn, ok := stateGetter.States[constants.FieldStorageClassLonghorn].([]map[string]interface{}); !ok || len(longhorn) != 0 {
+		t.Errorf("expected no longhorn block, got %v", stateGetter.States[constants.FieldStorageClassLonghorn])
+	}
+}
+
+// newTestStorageClass returns the storage class of the longhorn parameters,
+// as the constructor creates it, with another parameter.
+func newTestStorageClass(longhornParameters map[string]string) *storagev1.StorageClass {
+	parameters := map[string]string{"other": "value"}
+	for key, value := range longhornParameters {
+		parameters[key] = value
+	}
+	reclaimPolicy := corev1.PersistentVolumeReclaimDelete
+	volumeBindingMode := storagev1.VolumeBindingImmediate
+	allowVolumeExpansion := true
+	return &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "longhorn-test",
+			Annotations: map[string]string{
+				constants.AnnotationStorageClassLonghornParameters: strings.Join(longhornParameterKeys(longhornParameters), ","),
+			},
+		},
+		Provisioner:          LonghornDriverName,
+		Parameters:           parameters,
+		ReclaimPolicy:        &reclaimPolicy,
+		VolumeBindingMode:    &volumeBindingMode,
+		AllowVolumeExpansion: &allowVolumeExpansion,
+	}
+}
@@ -26,7 +26,8 @@ func ResourceStorageClass() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: Schema(),
+		Schema:        Schema(),
+		CustomizeDiff: resourceStorageClassCustomizeDiff,
 		Timeouts: &schema.ResourceTimeout{
 			Create:  schema.DefaultTimeout(2 * time.Minute),
 			Read:    schema.DefaultTimeout(2 * time.Minute),
@@ -1,6 +1,8 @@
 package storageclass
 
 import (
+	"strings"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -22,6 +24,7 @@ func (c *Constructor) Setup() util.Processors {
 		Tags(&c.StorageClass.Labels).
 		Labels(&c.StorageClass.Labels).
 		Description(&c.StorageClass.Annotations).
+		DeletionProtection(&c.StorageClass.Annotations).
 		String(constants.FieldStorageClassVolumeProvisioner, &c.StorageClass.Provisioner, true)
 	customProcessors := []util.Processor{
 		{
@@ -67,6 +70,28 @@ func (c *Constructor) Setup() util.Processors {
 				return nil
 			},
 		},
+		{
+			// after the parameters, which are merged with the parameters of the longhorn block
+			Field: constants.FieldStorageClassLonghorn,
+			Parser: func(i interface{}) error {
+				r, ok := i.(map[string]interface{})
+				if !ok {
+					return nil
+				}
+				parameters, err := expandLonghornParameters(r)
+				if err != nil {
+					return err
+				}
+				if c.StorageClass.Parameters == nil {
+					c.StorageClass.Parameters = map[string]string{}
+				}
+				for key, value := range parameters {
+					c.StorageClass.Parameters[key] = value
+				}
+				c.StorageClass.Annotations[constants.AnnotationStorageClassLonghornParameters] = strings.Join(longhornParameterKeys(parameters), ",")
+				return nil
+			},
+		},
 		{
 			Field: constants.FieldStorageClassAllowedTopologies,
 			Parser: func(i interface{}) error {
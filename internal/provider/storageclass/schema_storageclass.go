@@ -52,8 +52,18 @@ func Schema() map[string]*schema.Schema {
 		},
 		constants.FieldStorageClassParameters: {
 			Type:        schema.TypeMap,
-			Required:    true,
-			Description: "refer to https://longhorn.io/docs/latest/volumes-and-nodes/storage-tags. \"migratable\": \"true\" is required for Harvester Virtual Machine LiveMigration",
+			Optional:    true,
+			Description: "refer to https://longhorn.io/docs/latest/volumes-and-nodes/storage-tags. \"migratable\": \"true\" is required for Harvester Virtual Machine LiveMigration. The parameters of Longhorn are validated when they are set with the longhorn block instead",
+		},
+		constants.FieldStorageClassLonghorn: {
+			Type:        schema.TypeList,
+			Optional:    true,
+			ForceNew:    true,
+			MaxItems:    1,
+			Description: "The parameters of the Longhorn provisioner, which are merged into parameters",
+			Elem: &schema.Resource{
+				Schema: longhornSchema(),
+			},
 		},
 		constants.FieldStorageClassAllowedTopologies: {
 			Type:        schema.TypeList,
@@ -88,9 +98,128 @@ func Schema() map[string]*schema.Schema {
 		},
 	}
 	util.NonNamespacedSchemaWrap(s)
+	util.DeletionProtectionSchemaWrap(s)
 	return s
 }
 
+func longhornSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		constants.FieldLonghornNumberOfReplicas: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validation.IntBetween(1, 20),
+			Description:  "The number of replicas of the volumes",
+		},
+		constants.FieldLonghornStaleReplicaTimeout: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "Minutes after which a failed replica is removed",
+		},
+		constants.FieldLonghornDiskSelector: {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Only schedule replicas on disks with all the tags",
+		},
+		constants.FieldLonghornNodeSelector: {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Only schedule replicas on nodes with all the tags",
+		},
+		constants.FieldLonghornDataLocality: {
+			Type:     schema.TypeString,
+			Optional: true,
+			ValidateFunc: validation.StringInSlice([]string{
+				constants.LonghornDataLocalityDisabled,
+				constants.LonghornDataLocalityBestEffort,
+				constants.LonghornDataLocalityStrictLocal,
+			}, false),
+		},
+		constants.FieldLonghornEncrypted: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Encrypt the volumes with the passphrase in the encryption secret",
+		},
+		constants.FieldLonghornEncryptionSecretName: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringIsNotWhiteSpace,
+			Description:  "The name of the secret of encrypted volumes, which is required when encrypted is true",
+		},
+		constants.FieldLonghornEncryptionSecretNamespace: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringIsNotWhiteSpace,
+			Description:  "The namespace of the secret of encrypted volumes, which is required when encrypted is true",
+		},
+		constants.FieldLonghornMigratable: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			Description: "Allow the live migration of virtual machines with the volumes",
+		},
+		constants.FieldLonghornRecurringJobSelector: {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "The recurring jobs and recurring job groups of the volumes",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					constants.FieldLonghornRecurringJobSelectorName: {
+						Type:         schema.TypeString,
+						Required:     true,
+						ValidateFunc: validation.StringIsNotWhiteSpace,
+					},
+					constants.FieldLonghornRecurringJobSelectorIsGroup: {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "The name is the name of a recurring job group",
+					},
+				},
+			},
+		},
+		constants.FieldLonghornBackingImage: {
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Description: "The Longhorn backing image of the volumes",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					constants.FieldLonghornBackingImageName: {
+						Type:         schema.TypeString,
+						Required:     true,
+						ValidateFunc: validation.StringIsNotWhiteSpace,
+					},
+					constants.FieldLonghornBackingImageDataSourceType: {
+						Type:     schema.TypeString,
+						Optional: true,
+						ValidateFunc: validation.StringInSlice([]string{
+							constants.LonghornBackingImageDataSourceTypeDownload,
+							constants.LonghornBackingImageDataSourceTypeUpload,
+							constants.LonghornBackingImageDataSourceTypeExportFromVolume,
+						}, false),
+						Description: "How the backing image is created when it doesn't exist",
+					},
+					constants.FieldLonghornBackingImageDataSourceParameters: {
+						Type:        schema.TypeMap,
+						Optional:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Description: "The parameters of the data source, e.g. url for download",
+					},
+					constants.FieldLonghornBackingImageChecksum: {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "The SHA512 checksum of the backing image",
+					},
+				},
+			},
+		},
+	}
+}
+
 func DataSourceSchema() map[string]*schema.Schema {
 	s := util.DataSourceSchemaWrap(Schema())
 	return s
@@ -2,12 +2,15 @@ package virtualmachine
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	harvsterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	harvesterutil "github.com/harvester/harvester/pkg/util"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -31,6 +34,7 @@ func ResourceVirtualMachine() *schema.Resource {
 		ReadContext:   resourceVirtualMachineRead,
 		DeleteContext: resourceVirtualMachineDelete,
 		UpdateContext: resourceVirtualMachineUpdate,
+		CustomizeDiff: resourceVirtualMachineCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -45,6 +49,34 @@ func ResourceVirtualMachine() *schema.Resource {
 	}
 }
 
+// resourceVirtualMachineCustomizeDiff rejects the features which are not
+// supported by the Harvester version at plan time.
+func resourceVirtualMachineCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	c := meta.(*config.Config)
+	capabilities := map[string]config.Capability{
+		constants.FieldVirtualMachineCPUPinning:            config.CapabilityCPUPinning,
+		constants.FieldVirtualMachineIsolateEmulatorThread: config.CapabilityIsolateEmulatorThread,
+		constants.FieldVirtualMachineRequests:              config.CapabilityVirtualMachineRequests,
+	}
+	rawConfig := d.GetRawConfig()
+	for field, capability := range capabilities {
+		if !d.HasChange(field) || rawConfig.IsNull() {
+			continue
+		}
+		// requests is computed, only the configured values use the feature
+		value := rawConfig.GetAttr(field)
+		if value.IsNull() || !value.IsKnown() ||
+			(value.Type() == cty.Bool && value.False()) ||
+			(value.CanIterateElements() && value.LengthInt() == 0) {
+			continue
+		}
+		if err := c.CheckCapability(capability); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func resourceVirtualMachineCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	c, err := meta.(*config.Config).K8sClient()
 	if err != nil {
@@ -64,6 +96,17 @@ func resourceVirtualMachineCreate(ctx context.Context, d *schema.ResourceData, m
 	if err = updateLocalFields(d, constants.FieldVirtualMachineRestartAfterUpdate, constants.FieldVirtualMachineCreateInitialSnapshot); err != nil {
 		return diag.FromErr(err)
 	}
+	// the volumes restored from snapshots or cloned are waited for, since a
+	// stopped virtual machine doesn't wait for its volumes
+	dataSourceVolumeNames, err := getDataSourceVolumeNames(vm)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, volumeName := range dataSourceVolumeNames {
+		if err = util.WaitForVolumeBound(ctx, c, namespace, volumeName); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	runStrategy, err := vm.RunStrategy()
 	if err != nil {
 		return diag.FromErr(err)
@@ -183,6 +226,15 @@ func resourceVirtualMachineDelete(ctx context.Context, d *schema.ResourceData, m
 	}
 
 	removedPVCs := getRemovedPVCs(d, vm)
+	// recording the virtual machine on the kept volumes doesn't block the delete
+	for _, claimName := range getKeptPVCs(vm, removedPVCs) {
+		if err = util.SetVolumeLastAttachedVM(ctx, c, namespace, claimName, name); err != nil {
+			tflog.Warn(ctx, "failed to record the last attached virtual machine of the volume", map[string]interface{}{
+				"volume": namespace + "/" + claimName,
+				"error":  err.Error(),
+			})
+		}
+	}
 	vmCopy := vm.DeepCopy()
 	vmCopy.Annotations[harvesterutil.RemovedPVCsAnnotationKey] = strings.Join(removedPVCs, ",")
 	_, err = c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).Update(ctx, vmCopy, metav1.UpdateOptions{})
@@ -309,9 +361,11 @@ func getRemovedPVCs(d *schema.ResourceData, vm *kubevirtv1.VirtualMachine) []str
 			deleteConfigs[diskName] = r[constants.FieldDiskAutoDelete].(bool)
 		}
 	}
+	// the volumes of volume attachments are never deleted with the virtual machine
+	attachments := importer.GetVolumeAttachments(vm.Annotations)
 	removedPVCs := make([]string, 0, len(vm.Spec.Template.Spec.Volumes))
 	for _, volume := range vm.Spec.Template.Spec.Volumes {
-		if volume.PersistentVolumeClaim == nil {
+		if volume.PersistentVolumeClaim == nil || attachments[volume.Name] {
 			continue
 		}
 		if autoDelete, ok := deleteConfigs[volume.Name]; ok && !autoDelete {
@@ -322,6 +376,42 @@ func getRemovedPVCs(d *schema.ResourceData, vm *kubevirtv1.VirtualMachine) []str
 	return removedPVCs
 }
 
+// getKeptPVCs returns the PVCs of the virtual machine which are not deleted
+// with it.
+func getKeptPVCs(vm *kubevirtv1.VirtualMachine, removedPVCs []string) []string {
+	removed := make(map[string]bool, len(removedPVCs))
+	for _, claimName := range removedPVCs {
+		removed[claimName] = true
+	}
+	var keptPVCs []string
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil && !removed[volume.PersistentVolumeClaim.ClaimName] {
+			keptPVCs = append(keptPVCs, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return keptPVCs
+}
+
+// getDataSourceVolumeNames returns the volume claim templates of the virtual
+// machine which are restored from volume snapshots or cloned from volumes.
+func getDataSourceVolumeNames(vm *kubevirtv1.VirtualMachine) ([]string, error) {
+	volumeClaimTemplates := vm.Annotations[harvesterutil.AnnotationVolumeClaimTemplates]
+	if volumeClaimTemplates == "" {
+		return nil, nil
+	}
+	var pvcTemplates []*corev1.PersistentVolumeClaim
+	if err := json.Unmarshal([]byte(volumeClaimTemplates), &pvcTemplates); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pvcTemplate := range pvcTemplates {
+		if importer.GetSourceSnapshot(pvcTemplate.Spec.DataSource) != "" || importer.GetSourceVolume(pvcTemplate.Spec.DataSource) != "" {
+			names = append(names, pvcTemplate.Name)
+		}
+	}
+	return names, nil
+}
+
 func createInitialSnapshot(ctx context.Context, c *client.Client, namespace, vmName string) error {
 	snapshotName := fmt.Sprintf("%s-initial", vmName)
 
@@ -2,11 +2,13 @@ package virtualmachine
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
@@ -19,6 +21,7 @@ import (
 	"github.com/harvester/terraform-provider-harvester/pkg/client"
 	"github.com/harvester/terraform-provider-harvester/pkg/constants"
 	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+	"github.com/harvester/terraform-provider-harvester/pkg/importer"
 )
 
 const (
@@ -34,6 +37,10 @@ type Constructor struct {
 	Context context.Context
 
 	Builder *builder.VMBuilder
+
+	// dataSources are the volume snapshots and volumes which the volumes of
+	// the disks are restored or cloned from, by disk name.
+	dataSources map[string]*corev1.TypedLocalObjectReference
 }
 
 func (c *Constructor) Setup() util.Processors {
@@ -44,7 +51,8 @@ func (c *Constructor) Setup() util.Processors {
 	processors := util.NewProcessors().
 		Tags(&c.Builder.VirtualMachine.Labels).
 		Labels(&c.Builder.VirtualMachine.Labels).
-		Description(&c.Builder.VirtualMachine.Annotations)
+		Description(&c.Builder.VirtualMachine.Annotations).
+		DeletionProtection(&c.Builder.VirtualMachine.Annotations)
 
 	customProcessors := []util.Processor{
 		{
@@ -243,6 +251,8 @@ func (c *Constructor) Setup() util.Processors {
 				existingVolumeName := r[constants.FieldDiskExistingVolumeName].(string)
 				containerImageName := r[constants.FieldDiskContainerImageName].(string)
 				hotPlug := r[constants.FieldDiskHotPlug].(bool)
+				sourceSnapshot := r[constants.FieldDiskSourceSnapshot].(string)
+				sourceVolume := r[constants.FieldDiskSourceVolume].(string)
 				isCDRom := diskType == builder.DiskTypeCDRom
 				if diskBus == "" {
 					if isCDRom {
@@ -263,6 +273,15 @@ func (c *Constructor) Setup() util.Processors {
 					}
 				}
 
+				if sourceSnapshot != "" && sourceVolume != "" {
+					return fmt.Errorf("%s and %s of disk %s can't be used together", constants.FieldDiskSourceSnapshot, constants.FieldDiskSourceVolume, diskName)
+				}
+				for field, source := range map[string]string{constants.FieldDiskSourceSnapshot: sourceSnapshot, constants.FieldDiskSourceVolume: sourceVolume} {
+					if source != "" && (existingVolumeName != "" || containerImageName != "" || imageNamespacedName != "") {
+						return fmt.Errorf("%s of disk %s can't be used with %s, %s or %s", field, diskName,
+							constants.FieldDiskExistingVolumeName, constants.FieldDiskContainerImageName, constants.FieldVolumeImage)
+					}
+				}
 				if existingVolumeName != "" {
 					vmBuilder.ExistingPVCVolume(diskName, existingVolumeName, hotPlug)
 				} else if containerImageName != "" {
@@ -292,7 +311,8 @@ func (c *Constructor) Setup() util.Processors {
 						} else if storageClassName != scName {
 							return fmt.Errorf("the %s of an image can only be defined during image creation", constants.FieldVolumeStorageClassName)
 						}
-					} else {
+					} else if sourceVolume == "" {
+						// a clone takes the storage class of its source volume
 						if storageClassName == "" {
 							storageClasses, err := c.Client.StorageClassClient.StorageClasses().List(c.Context, metav1.ListOptions{})
 							if err != nil {
@@ -308,7 +328,8 @@ func (c *Constructor) Setup() util.Processors {
 					}
 					pvcOption.StorageClassName = ptr.To(storageClassName)
 
-					if volumeMode := r[constants.FieldVolumeMode].(string); volumeMode != "" {
+					volumeMode := r[constants.FieldVolumeMode].(string)
+					if volumeMode != "" {
 						pvcOption.VolumeMode = corev1.PersistentVolumeMode(volumeMode)
 					}
 					if accessMode := r[constants.FieldVolumeAccessMode].(string); accessMode != "" {
@@ -320,14 +341,26 @@ func (c *Constructor) Setup() util.Processors {
 						}
 					}
 
-					_, err := resource.ParseQuantity(diskSize)
+					if _, err := resource.ParseQuantity(diskSize); diskSize != "" && err != nil {
+						return fmt.Errorf("\"%v\" is not a parsable quantity: %v", diskSize, err)
+					}
+					if sourceVolume != "" {
+						var err error
+						if diskSize, err = c.checkVolumeClone(volumeName, sourceVolume, volumeMode != "", pvcOption, diskSize); err != nil {
+							return err
+						}
+					}
 					if diskSize == "" {
 						diskSize = builder.DefaultDiskSize
-					} else if err != nil {
-						return fmt.Errorf("\"%v\" is not a parsable quantity: %v", diskSize, err)
 					}
 
 					vmBuilder.PVCVolume(diskName, diskSize, volumeName, hotPlug, pvcOption)
+					switch {
+					case sourceSnapshot != "":
+						c.dataSources[diskName] = util.NewVolumeSnapshotDataSource(sourceSnapshot)
+					case sourceVolume != "":
+						c.dataSources[diskName] = util.NewVolumeDataSource(sourceVolume)
+					}
 				}
 				return nil
 			},
@@ -477,14 +510,103 @@ func (c *Constructor) Validate() error {
 }
 
 func (c *Constructor) Result() (interface{}, error) {
-	return c.Builder.VM()
+	vm, err := c.Builder.VM()
+	if err != nil || len(c.dataSources) == 0 {
+		return vm, err
+	}
+	if err = c.setDataSources(vm); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+// setDataSources sets the data sources of the volume claim templates of the
+// disks. It waits for the snapshots of the volumes which don't exist yet to be
+// ready to use.
+func (c *Constructor) setDataSources(vm *kubevirtv1.VirtualMachine) error {
+	claimNames := map[string]string{}
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			claimNames[volume.Name] = volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+	var pvcTemplates []*corev1.PersistentVolumeClaim
+	if err := json.Unmarshal([]byte(vm.Annotations[harvesterutil.AnnotationVolumeClaimTemplates]), &pvcTemplates); err != nil {
+		return err
+	}
+	for diskName, dataSource := range c.dataSources {
+		for _, pvcTemplate := range pvcTemplates {
+			if pvcTemplate.Name != claimNames[diskName] {
+				continue
+			}
+			pvcTemplate.Spec.DataSource = dataSource
+			if dataSource.Kind != constants.VolumeSnapshotKind {
+				continue
+			}
+			_, err := c.Client.KubeClient.CoreV1().PersistentVolumeClaims(vm.Namespace).Get(c.Context, pvcTemplate.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				err = util.WaitForVolumeSnapshotReady(c.Context, c.Client, vm.Namespace, dataSource.Name)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	volumeClaimTemplates, err := json.Marshal(pvcTemplates)
+	if err != nil {
+		return err
+	}
+	vm.Annotations[harvesterutil.AnnotationVolumeClaimTemplates] = string(volumeClaimTemplates)
+	return nil
+}
+
+// checkVolumeClone checks the volume of a disk which is cloned from the
+// source volume, the storage class, volume mode and size which are not set
+// on the disk are taken from the source volume. It returns the size of the
+// disk. The clones which already exist are not checked again.
+func (c *Constructor) checkVolumeClone(volumeName, sourceVolume string, hasVolumeMode bool, pvcOption *builder.PersistentVolumeClaimOption, diskSize string) (string, error) {
+	namespace := c.Builder.VirtualMachine.Namespace
+	if volumeName != "" {
+		_, err := c.Client.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(c.Context, volumeName, metav1.GetOptions{})
+		if err == nil {
+			return diskSize, nil
+		} else if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+	}
+
+	clone := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: pvcOption.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{},
+			},
+		},
+	}
+	if hasVolumeMode {
+		clone.Spec.VolumeMode = ptr.To(pvcOption.VolumeMode)
+	}
+	if diskSize != "" {
+		clone.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse(diskSize)
+	}
+	if err := util.CheckVolumeCloneSource(c.Context, c.Client, clone, sourceVolume); err != nil {
+		return "", err
+	}
+	pvcOption.StorageClassName = clone.Spec.StorageClassName
+	if clone.Spec.VolumeMode != nil {
+		pvcOption.VolumeMode = *clone.Spec.VolumeMode
+	}
+	size := clone.Spec.Resources.Requests[corev1.ResourceStorage]
+	return size.String(), nil
 }
 
 func newVMConstructor(c *client.Client, ctx context.Context, vmBuilder *builder.VMBuilder) util.Constructor {
 	return &Constructor{
-		Client:  c,
-		Context: ctx,
-		Builder: vmBuilder,
+		Client:      c,
+		Context:     ctx,
+		Builder:     vmBuilder,
+		dataSources: map[string]*corev1.TypedLocalObjectReference{},
 	}
 }
 
@@ -497,12 +619,26 @@ func Creator(c *client.Client, ctx context.Context, namespace, name string) util
 }
 
 func Updater(c *client.Client, ctx context.Context, vm *kubevirtv1.VirtualMachine) util.Constructor {
+	// the disks of volume attachments are kept, they are managed apart
+	attachments := importer.GetVolumeAttachments(vm.Annotations)
+	disks := []kubevirtv1.Disk{}
+	for _, disk := range vm.Spec.Template.Spec.Domain.Devices.Disks {
+		if attachments[disk.Name] {
+			disks = append(disks, disk)
+		}
+	}
+	volumes := []kubevirtv1.Volume{}
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if attachments[volume.Name] {
+			volumes = append(volumes, volume)
+		}
+	}
 	vm.Spec.Template.Spec.Networks = []kubevirtv1.Network{}
 	vm.Spec.Template.Spec.Domain.Devices.TPM = nil
 	vm.Spec.Template.Spec.Domain.Devices.Interfaces = []kubevirtv1.Interface{}
-	vm.Spec.Template.Spec.Domain.Devices.Disks = []kubevirtv1.Disk{}
+	vm.Spec.Template.Spec.Domain.Devices.Disks = disks
 	vm.Spec.Template.Spec.Domain.Devices.Inputs = []kubevirtv1.Input{}
-	vm.Spec.Template.Spec.Volumes = []kubevirtv1.Volume{}
+	vm.Spec.Template.Spec.Volumes = volumes
 	vm.Annotations[harvesterutil.AnnotationVolumeClaimTemplates] = "[]"
 	return newVMConstructor(c, ctx, &builder.VMBuilder{
 		VirtualMachine: vm,
@@ -0,0 +1,157 @@
+package virtualmachine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/harvester/harvester/pkg/builder"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+)
+
+// newTestCloneConstructor returns the constructor of a virtual machine in the
+// namespace default, whose API serves the bound 10Gi block volume data and the
+// clone vm-rootdisk, which already exists. Reading the volume forbidden fails.
+func newTestCloneConstructor(t *testing.T) *Constructor {
+	volumes := map[string]*corev1.PersistentVolumeClaim{
+		"data": {
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To("longhorn"),
+				VolumeMode:       ptr.To(corev1.PersistentVolumeBlock),
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		},
+		"vm-rootdisk": {
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm-rootdisk"},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		name := strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/default/persistentvolumeclaims/")
+		if pvc, ok := volumes[name]; ok && r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(pvc)
+			return
+		}
+		status := &metav1.Status{Status: metav1.StatusFailure, Code: http.StatusNotFound, Reason: metav1.StatusReasonNotFound}
+		if name == "forbidden" {
+			status.Code, status.Reason, status.Message = http.StatusForbidden, metav1.StatusReasonForbidden, `persistentvolumeclaims "forbidden" is forbidden`
+		}
+		w.WriteHeader(int(status.Code))
+		_ = json.NewEncoder(w).Encode(status)
+	}))
+	t.Cleanup(server.Close)
+	c, err := client.NewClientForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newVMConstructor(c, context.Background(), builder.NewVMBuilder(vmCreator).Namespace("default").Name("vm")).(*Constructor)
+}
+
+func TestCheckVolumeClone(t *testing.T) {
+	testCases := []struct {
+		name               string
+		volumeName         string
+		sourceVolume       string
+		volumeMode         corev1.PersistentVolumeMode
+		diskSize           string
+		expectSize         string
+		expectStorageClass string
+		expectVolumeMode   corev1.PersistentVolumeMode
+		expectError        string
+	}{
+		{
+			name:               "defaults of the source",
+			sourceVolume:       "data",
+			expectSize:         "10Gi",
+			expectStorageClass: "longhorn",
+			expectVolumeMode:   corev1.PersistentVolumeBlock,
+		},
+		{
+			name:               "larger disk",
+			volumeName:         "vm-disk",
+			sourceVolume:       "data",
+			volumeMode:         corev1.PersistentVolumeBlock,
+			diskSize:           "20Gi",
+			expectSize:         "20Gi",
+			expectStorageClass: "longhorn",
+			expectVolumeMode:   corev1.PersistentVolumeBlock,
+		},
+		{
+			name:         "existing clone isn't checked again",
+			volumeName:   "vm-rootdisk",
+			sourceVolume: "deleted",
+			diskSize:     "5Gi",
+			expectSize:   "5Gi",
+		},
+		{
+			name:         "smaller disk",
+			sourceVolume: "data",
+			diskSize:     "5Gi",
+			expectError:  "is smaller than the size 10Gi of the source volume default/data",
+		},
+		{
+			name:         "other volume mode",
+			sourceVolume: "data",
+			volumeMode:   corev1.PersistentVolumeFilesystem,
+			expectError:  `must be the volume mode "Block" of the source volume default/data`,
+		},
+		{
+			name:         "missing source",
+			sourceVolume: "missing",
+			expectError:  "failed to get the source volume default/missing",
+		},
+		{
+			name:         "volume which can't be read",
+			volumeName:   "forbidden",
+			sourceVolume: "data",
+			expectError:  "forbidden",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestCloneConstructor(t)
+			pvcOption := &builder.PersistentVolumeClaimOption{}
+			if tc.volumeMode != "" {
+				pvcOption.VolumeMode = tc.volumeMode
+			}
+
+			size, err := c.checkVolumeClone(tc.volumeName, tc.sourceVolume, tc.volumeMode != "", pvcOption, tc.diskSize)
+			if tc.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+					t.Fatalf("expected an error containing %q, got %v", tc.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != tc.expectSize {
+				t.Errorf("expected the size %s, got %s", tc.expectSize, size)
+			}
+			if tc.expectStorageClass == "" {
+				return
+			}
+			if pvcOption.StorageClassName == nil || *pvcOption.StorageClassName != tc.expectStorageClass {
+				t.Errorf("expected the storage class %s, got %v", tc.expectStorageClass, pvcOption.StorageClassName)
+			}
+			if pvcOption.VolumeMode != tc.expectVolumeMode {
+				t.Errorf("expected the volume mode %s, got %s", tc.expectVolumeMode, pvcOption.VolumeMode)
+			}
+		})
+	}
+}
@@ -202,6 +202,7 @@ please use %s instead of this deprecated field:
 		},
 	}
 	util.NamespacedSchemaWrap(s, false)
+	util.DeletionProtectionSchemaWrap(s)
 	s[constants.FieldCommonTags].Description = "The tag is reflected as label on the VM.\n" +
 		"For example: `sample-tag = sample` adds label `tag.harvesterhci.io/sample-tag: sample`.\n" +
 		"For `ssh-user` tag, the value is added to `cloudinit.user_data` if:\n" +
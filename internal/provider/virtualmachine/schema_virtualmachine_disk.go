@@ -104,6 +104,18 @@ func resourceDiskSchema() map[string]*schema.Schema {
 				builder.PersistentVolumeAccessModeReadWriteMany,
 			}, false),
 		},
+		constants.FieldDiskSourceSnapshot: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "The name of a volume snapshot in the namespace of the virtual machine, which the volume of the disk is restored from",
+		},
+		constants.FieldDiskSourceVolume: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "The name of a volume in the namespace of the virtual machine, which the volume of the disk is cloned from. The storage class, volume mode and size default to the ones of the source volume",
+		},
 		constants.FieldDiskVolumeName: {
 			Type:         schema.TypeString,
 			Optional:     true,
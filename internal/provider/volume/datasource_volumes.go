@@ -0,0 +1,167 @@
+package volume
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harvester/harvester/pkg/builder"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+	"github.com/harvester/terraform-provider-harvester/pkg/importer"
+)
+
+func DataSourceVolumes() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceVolumesRead,
+		Schema:      VolumesDataSourceSchema(),
+	}
+}
+
+func dataSourceVolumesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	cfg := meta.(*config.Config)
+	c, err := cfg.K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace := d.Get(constants.FieldCommonNamespace).(string)
+
+	// labels and tags are matched by the API server
+	selector := labels.Set{}
+	for k, v := range d.Get(constants.FieldCommonLabels).(map[string]interface{}) {
+		selector[k] = v.(string)
+	}
+	for k, v := range d.Get(constants.FieldCommonTags).(map[string]interface{}) {
+		selector[builder.LabelPrefixHarvesterTag+k] = v.(string)
+	}
+	pvcs, err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// the virtual machines are listed once rather than for every volume
+	vms, err := c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	attachedVMs := map[string][]string{}
+	for _, vm := range vms.Items {
+		if vm.Spec.Template == nil {
+			continue
+		}
+		for _, volume := range vm.Spec.Template.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				key := ref.Construct(vm.Namespace, volume.PersistentVolumeClaim.ClaimName)
+				attachedVMs[key] = append(attachedVMs[key], ref.Construct(vm.Namespace, vm.Name))
+			}
+		}
+	}
+
+	unattached := d.Get(constants.FieldVolumesUnattached).(bool)
+	storageClassName := d.Get(constants.FieldVolumeStorageClassName).(string)
+	var createdBefore time.Time
+	if v := d.Get(constants.FieldVolumesOlderThan).(string); v != "" {
+		olderThan, err := time.ParseDuration(v)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		createdBefore = time.Now().Add(-olderThan)
+	}
+
+	var matches []*corev1.PersistentVolumeClaim
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		// the volumes of all namespaces are limited to the provider allowed_namespaces
+		if namespace == "" && cfg.CheckNamespaceAllowed(pvc.Namespace) != nil {
+			continue
+		}
+		if unattached && len(attachedVMs[ref.Construct(pvc.Namespace, pvc.Name)]) > 0 {
+			continue
+		}
+		if storageClassName != "" && (pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != storageClassName) {
+			continue
+		}
+		if !createdBefore.IsZero() && !pvc.CreationTimestamp.Time.Before(createdBefore) {
+			continue
+		}
+		matches = append(matches, pvc)
+	}
+
+	// the oldest first, by namespace and name if they are created at the same time
+	sort.SliceStable(matches, func(i, j int) bool {
+		ti, tj := matches[i].CreationTimestamp, matches[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return helper.BuildID(matches[i].Namespace, matches[i].Name) < helper.BuildID(matches[j].Namespace, matches[j].Name)
+	})
+
+	ids := make([]string, 0, len(matches))
+	list := make([]interface{}, 0, len(matches))
+	for _, pvc := range matches {
+		volume, err := dataSourceVolumesFlatten(pvc, attachedVMs[ref.Construct(pvc.Namespace, pvc.Name)])
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		ids = append(ids, volume["id"].(string))
+		list = append(list, volume)
+	}
+	hash := sha256.Sum256([]byte(namespace + "/" + strings.Join(ids, ",")))
+	d.SetId(hex.EncodeToString(hash[:]))
+	if err = d.Set(constants.FieldVolumesIDs, ids); err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(d.Set(constants.FieldVolumesVolumes, list))
+}
+
+func dataSourceVolumesFlatten(pvc *corev1.PersistentVolumeClaim, attachedVMs []string) (map[string]interface{}, error) {
+	volume := map[string]interface{}{
+		"id":                                  helper.BuildID(pvc.Namespace, pvc.Name),
+		constants.FieldCommonName:             pvc.Name,
+		constants.FieldCommonNamespace:        pvc.Namespace,
+		constants.FieldCommonDescription:      importer.GetDescriptions(pvc.Annotations),
+		constants.FieldVolumeSize:             pvc.Spec.Resources.Requests.Storage().String(),
+		constants.FieldPhase:                  string(pvc.Status.Phase),
+		constants.FieldVolumeAttachedVM:       strings.Join(attachedVMs, ","),
+		constants.FieldVolumeLastAttachedVM:   importer.GetLastAttachedVM(pvc.Annotations),
+		constants.FieldVolumesCreationTime:    pvc.CreationTimestamp.UTC().Format(time.RFC3339),
+		constants.FieldCommonState:            constants.StateCommonReady,
+		constants.FieldCommonLabels:           importer.GetLabels(pvc.Labels),
+		constants.FieldCommonTags:             importer.GetTags(pvc.Labels),
+		constants.FieldVolumeStorageClassName: "",
+		constants.FieldVolumeMode:             "",
+		constants.FieldVolumeImage:            "",
+	}
+	if len(attachedVMs) > 0 {
+		volume[constants.FieldCommonState] = constants.StateVolumeInUse
+		// a volume which is attached now was last attached to its virtual machine
+		volume[constants.FieldVolumeLastAttachedVM] = attachedVMs[len(attachedVMs)-1]
+	}
+	if pvc.Spec.StorageClassName != nil {
+		volume[constants.FieldVolumeStorageClassName] = *pvc.Spec.StorageClassName
+	}
+	if pvc.Spec.VolumeMode != nil {
+		volume[constants.FieldVolumeMode] = string(*pvc.Spec.VolumeMode)
+	}
+	if imageID := pvc.Annotations[builder.AnnotationKeyImageID]; imageID != "" {
+		imageNamespacedName, err := helper.RebuildNamespacedName(imageID, pvc.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		volume[constants.FieldVolumeImage] = imageNamespacedName
+	}
+	return volume, nil
+}
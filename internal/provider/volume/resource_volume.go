@@ -2,6 +2,7 @@ package volume
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -9,7 +10,9 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/harvester/terraform-provider-harvester/internal/config"
 	"github.com/harvester/terraform-provider-harvester/internal/util"
@@ -30,7 +33,7 @@ func ResourceVolume() *schema.Resource {
 		},
 		Schema: Schema(),
 		Timeouts: &schema.ResourceTimeout{
-			Create:  schema.DefaultTimeout(2 * time.Minute),
+			Create:  schema.DefaultTimeout(10 * time.Minute),
 			Read:    schema.DefaultTimeout(2 * time.Minute),
 			Update:  schema.DefaultTimeout(2 * time.Minute),
 			Delete:  schema.DefaultTimeout(5 * time.Minute),
@@ -50,13 +53,59 @@ func resourceVolumeCreate(ctx context.Context, d *schema.ResourceData, meta inte
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	obj, err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, toCreate.(*corev1.PersistentVolumeClaim), metav1.CreateOptions{})
+	pvc := toCreate.(*corev1.PersistentVolumeClaim)
+	sourceSnapshot := d.Get(constants.FieldVolumeSourceSnapshot).(string)
+	sourceVolume := d.Get(constants.FieldVolumeSourceVolume).(string)
+	switch {
+	case sourceSnapshot != "":
+		err = checkSourceSnapshot(ctx, c, pvc, sourceSnapshot)
+	case sourceVolume != "":
+		err = util.CheckVolumeCloneSource(ctx, c, pvc, sourceVolume)
+	}
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	obj, err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if pvc.Spec.DataSource != nil {
+		d.SetId(helper.BuildID(namespace, name))
+		if err = util.WaitForVolumeBound(ctx, c, namespace, name); err != nil {
+			return diag.FromErr(err)
+		}
+		if obj, err = c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{}); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return diag.FromErr(resourceVolumeImport(d, c, obj))
 }
 
+// checkSourceSnapshot waits for the volume snapshot to be ready to use, and
+// checks that the volume is large enough to restore it.
+func checkSourceSnapshot(ctx context.Context, c *client.Client, pvc *corev1.PersistentVolumeClaim, sourceSnapshot string) error {
+	if err := util.WaitForVolumeSnapshotReady(ctx, c, pvc.Namespace, sourceSnapshot); err != nil {
+		return err
+	}
+	snapshot, err := c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(pvc.Namespace).Get(ctx, sourceSnapshot, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	restoreSize, _, _ := unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+	if restoreSize == "" {
+		return nil
+	}
+	minSize, err := resource.ParseQuantity(restoreSize)
+	if err != nil {
+		return fmt.Errorf("failed to parse the restore size of the volume snapshot %s/%s: %w", pvc.Namespace, sourceSnapshot, err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.Cmp(minSize) < 0 {
+		return fmt.Errorf("the %s %s is smaller than the restore size %s of the volume snapshot %s/%s",
+			constants.FieldVolumeSize, size.String(), minSize.String(), pvc.Namespace, sourceSnapshot)
+	}
+	return nil
+}
+
 func resourceVolumeUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	c, err := meta.(*config.Config).K8sClient()
 	if err != nil {
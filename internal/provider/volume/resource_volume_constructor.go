@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/harvester/harvester/pkg/builder"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/utils/ptr"
@@ -25,7 +26,8 @@ func (c *Constructor) Setup() util.Processors {
 	processors := util.NewProcessors().
 		Tags(&c.Volume.Labels).
 		Labels(&c.Volume.Labels).
-		Description(&c.Volume.Annotations)
+		Description(&c.Volume.Annotations).
+		DeletionProtection(&c.Volume.Annotations)
 
 	customProcessors := []util.Processor{
 		{
@@ -64,6 +66,23 @@ func (c *Constructor) Setup() util.Processors {
 			},
 			Required: true,
 		},
+		{
+			Field: constants.FieldVolumeRecurringJobGroups,
+			Parser: func(i interface{}) error {
+				if c.Volume.Labels == nil {
+					c.Volume.Labels = map[string]string{}
+				}
+				groups := i.(*schema.Set).List()
+				if len(groups) == 0 {
+					return nil
+				}
+				c.Volume.Labels[constants.LabelLonghornRecurringJobSource] = constants.LabelValueLonghornRecurringJobEnabled
+				for _, group := range groups {
+					c.Volume.Labels[constants.LabelPrefixLonghornRecurringJobGroup+group.(string)] = constants.LabelValueLonghornRecurringJobEnabled
+				}
+				return nil
+			},
+		},
 		{
 			Field: constants.FieldVolumeImage,
 			Parser: func(i interface{}) error {
@@ -78,6 +97,13 @@ func (c *Constructor) Setup() util.Processors {
 				return nil
 			},
 		},
+		{
+			Field: constants.FieldVolumeSourceSnapshot,
+			Parser: func(i interface{}) error {
+				c.Volume.Spec.DataSource = util.NewVolumeSnapshotDataSource(i.(string))
+				return nil
+			},
+		},
 		{
 			Field: constants.FieldVolumeStorageClassName,
 			Parser: func(i interface{}) error {
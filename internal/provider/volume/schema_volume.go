@@ -20,6 +20,22 @@ func Schema() map[string]*schema.Schema {
 			Type:     schema.TypeString,
 			Optional: true,
 		},
+		constants.FieldVolumeSourceSnapshot: {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ForceNew:      true,
+			ValidateFunc:  util.IsValidName,
+			ConflictsWith: []string{constants.FieldVolumeImage},
+			Description:   "The name of a volume snapshot in the namespace of the volume to restore, the size must be at least the restore size of the snapshot",
+		},
+		constants.FieldVolumeSourceVolume: {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ForceNew:      true,
+			ValidateFunc:  util.IsValidName,
+			ConflictsWith: []string{constants.FieldVolumeImage, constants.FieldVolumeSourceSnapshot},
+			Description:   "The name of a volume in the namespace of the volume to clone. The clone has the storage class and volume mode of the source volume, and the size must be at least its size",
+		},
 		constants.FieldVolumeStorageClassName: {
 			Type:         schema.TypeString,
 			Optional:     true,
@@ -45,6 +61,12 @@ func Schema() map[string]*schema.Schema {
 				builder.PersistentVolumeAccessModeReadWriteMany,
 			}, false),
 		},
+		constants.FieldVolumeRecurringJobGroups: {
+			Type:        schema.TypeSet,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "The Longhorn recurring job groups of the volume, which are set as the labels `" + constants.LabelPrefixLonghornRecurringJobGroup + "<group>`",
+		},
 		constants.FieldVolumeAttachedVM: {
 			Type:     schema.TypeString,
 			Computed: true,
@@ -55,9 +77,88 @@ func Schema() map[string]*schema.Schema {
 		},
 	}
 	util.NamespacedSchemaWrap(s, false)
+	util.DeletionProtectionSchemaWrap(s)
 	return s
 }
 
 func DataSourceSchema() map[string]*schema.Schema {
 	return util.DataSourceSchemaWrap(Schema())
 }
+
+func VolumesDataSourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		constants.FieldCommonNamespace: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "Only volumes in the namespace, volumes of all namespaces by default, limited to the provider `allowed_namespaces` when it is set",
+		},
+		constants.FieldVolumesUnattached: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Only volumes which are not used by any virtual machine",
+		},
+		constants.FieldVolumeStorageClassName: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Only volumes of the storage class",
+		},
+		constants.FieldCommonLabels: {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Only volumes with all the labels",
+		},
+		constants.FieldCommonTags: {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Only volumes with all the tags",
+		},
+		constants.FieldVolumesOlderThan: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: util.IsPositiveDuration,
+			Description:  "Only volumes created longer ago than the duration, e.g. 720h",
+		},
+		constants.FieldVolumesIDs: {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "IDs of the matching volumes, the oldest first",
+		},
+		constants.FieldVolumesVolumes: {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The matching volumes, the oldest first",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id":                                  {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonName:             {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonNamespace:        {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonDescription:      {Type: schema.TypeString, Computed: true},
+					constants.FieldVolumeSize:             {Type: schema.TypeString, Computed: true},
+					constants.FieldPhase:                  {Type: schema.TypeString, Computed: true},
+					constants.FieldVolumeStorageClassName: {Type: schema.TypeString, Computed: true},
+					constants.FieldVolumeMode:             {Type: schema.TypeString, Computed: true},
+					constants.FieldVolumeImage:            {Type: schema.TypeString, Computed: true},
+					constants.FieldVolumeAttachedVM:       {Type: schema.TypeString, Computed: true},
+					constants.FieldVolumeLastAttachedVM:   {Type: schema.TypeString, Computed: true},
+					constants.FieldVolumesCreationTime:    {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonState:            {Type: schema.TypeString, Computed: true},
+					constants.FieldCommonLabels: {
+						Type:     schema.TypeMap,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					constants.FieldCommonTags: {
+						Type:     schema.TypeMap,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,435 @@
+package volumeattachment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harvester/harvester/pkg/builder"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuberetry "k8s.io/client-go/util/retry"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+	"github.com/harvester/terraform-provider-harvester/pkg/importer"
+)
+
+func ResourceVolumeAttachment() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVolumeAttachmentCreate,
+		ReadContext:   resourceVolumeAttachmentRead,
+		DeleteContext: resourceVolumeAttachmentDelete,
+		CustomizeDiff: resourceVolumeAttachmentCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: Schema(),
+		Timeouts: &schema.ResourceTimeout{
+			Create:  schema.DefaultTimeout(5 * time.Minute),
+			Read:    schema.DefaultTimeout(2 * time.Minute),
+			Delete:  schema.DefaultTimeout(5 * time.Minute),
+			Default: schema.DefaultTimeout(2 * time.Minute),
+		},
+	}
+}
+
+// hotPlugBuses are the disk buses which KubeVirt can hot plug.
+var hotPlugBuses = []string{builder.DiskBusScsi, builder.DiskBusVirtio}
+
+func resourceVolumeAttachmentCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	return validateHotPlugBus(d.Get(constants.FieldVolumeAttachmentHotPlug).(bool), d.Get(constants.FieldVolumeAttachmentBus).(string))
+}
+
+// validateHotPlugBus returns an error if the volume is hot plugged with a bus
+// which KubeVirt can't hot plug, an empty bus is defaulted.
+func validateHotPlugBus(hotPlug bool, bus string) error {
+	if !hotPlug || bus == "" || slices.Contains(hotPlugBuses, bus) {
+		return nil
+	}
+	return fmt.Errorf("the %s %s can't be hot plugged, it must be one of %s", constants.FieldVolumeAttachmentBus, bus, strings.Join(hotPlugBuses, ", "))
+}
+
+func resourceVolumeAttachmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace := d.Get(constants.FieldCommonNamespace).(string)
+	vmName := d.Get(constants.FieldVolumeAttachmentVirtualMachineName).(string)
+	volumeName := d.Get(constants.FieldVolumeAttachmentVolumeName).(string)
+	hotPlug := d.Get(constants.FieldVolumeAttachmentHotPlug).(bool)
+	diskName := d.Get(constants.FieldVolumeAttachmentDiskName).(string)
+	if diskName == "" {
+		diskName = volumeName
+	}
+	bus := d.Get(constants.FieldVolumeAttachmentBus).(string)
+	if bus == "" {
+		bus = builder.DiskBusVirtio
+		if hotPlug {
+			bus = builder.DiskBusScsi
+		}
+	}
+
+	if _, err = c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, volumeName, metav1.GetOptions{}); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to get volume %s/%s: %w", namespace, volumeName, err))
+	}
+	running, err := isVirtualMachineRunning(ctx, c, namespace, vmName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if running && !hotPlug {
+		return diag.FromErr(fmt.Errorf("virtual machine %s/%s is running, a volume can only be attached to it with %s", namespace, vmName, constants.FieldVolumeAttachmentHotPlug))
+	}
+
+	disk := kubevirtv1.Disk{
+		Name: diskName,
+		DiskDevice: kubevirtv1.DiskDevice{
+			Disk: &kubevirtv1.DiskTarget{Bus: kubevirtv1.DiskBus(bus)},
+		},
+	}
+	volumeSource := &kubevirtv1.PersistentVolumeClaimVolumeSource{
+		PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: volumeName},
+		Hotpluggable:                      hotPlug,
+	}
+
+	// The disk is recorded on the virtual machine first, so that it is left
+	// alone by the harvester_virtualmachine resource. The disk of a stopped
+	// virtual machine is added to its spec at the same time.
+	if err = updateVirtualMachine(ctx, c, namespace, vmName, func(vm *kubevirtv1.VirtualMachine) error {
+		for _, existing := range vm.Spec.Template.Spec.Volumes {
+			if existing.Name == diskName {
+				return fmt.Errorf("virtual machine %s/%s already has a disk %s", namespace, vmName, diskName)
+			}
+			if existing.PersistentVolumeClaim != nil && existing.PersistentVolumeClaim.ClaimName == volumeName {
+				return fmt.Errorf("volume %s/%s is already attached to virtual machine %s/%s", namespace, volumeName, namespace, vmName)
+			}
+		}
+		setVolumeAttachment(vm, diskName, true)
+		if !running {
+			vm.Spec.Template.Spec.Domain.Devices.Disks = append(vm.Spec.Template.Spec.Domain.Devices.Disks, disk)
+			vm.Spec.Template.Spec.Volumes = append(vm.Spec.Template.Spec.Volumes, kubevirtv1.Volume{
+				Name:         diskName,
+				VolumeSource: kubevirtv1.VolumeSource{PersistentVolumeClaim: volumeSource},
+			})
+		}
+		return nil
+	}); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(helper.BuildID(namespace, vmName+helper.IDSep+diskName))
+
+	if running {
+		body, err := json.Marshal(&kubevirtv1.AddVolumeOptions{
+			Name:         diskName,
+			Disk:         &disk,
+			VolumeSource: &kubevirtv1.HotplugVolumeSource{PersistentVolumeClaim: volumeSource},
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if err = c.KubeVirtSubresourceClient.Put().Namespace(namespace).Resource(constants.ResourceVirtualMachine).SubResource(constants.SubresourceAddVolume).Name(vmName).Body(body).Do(ctx).Error(); err != nil {
+			err = fmt.Errorf("failed to hot plug volume %s/%s to virtual machine %s/%s: %w", namespace, volumeName, namespace, vmName, err)
+			if rollbackErr := rollbackVolumeAttachment(ctx, c, namespace, vmName, diskName); rollbackErr != nil {
+				// the ID is kept, so the next apply deletes the attachment
+				return diag.FromErr(errors.Join(err, rollbackErr))
+			}
+			d.SetId("")
+			return diag.FromErr(err)
+		}
+	}
+
+	stateConf := &retry.StateChangeConf{
+		Pending:    []string{constants.StateVolumeAttachmentAttaching},
+		Target:     []string{constants.StateVolumeAttachmentAttached},
+		Refresh:    resourceVolumeAttachmentRefresh(ctx, c, namespace, vmName, diskName),
+		Timeout:    d.Timeout(schema.TimeoutCreate),
+		Delay:      1 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+	if _, err = stateConf.WaitForStateContext(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceVolumeAttachmentRead(ctx, d, meta)
+}
+
+func resourceVolumeAttachmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace, vmName, diskName, err := volumeAttachmentIDParts(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	vm, err := c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).Get(ctx, vmName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	if !importer.GetVolumeAttachments(vm.Annotations)[diskName] || !hasVolume(vm, diskName) {
+		d.SetId("")
+		return nil
+	}
+	vmi, err := getVirtualMachineInstance(ctx, c, namespace, vmName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	stateGetter, err := importer.ResourceVolumeAttachmentStateGetter(vm, vmi, diskName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(util.ResourceStatesSet(d, stateGetter))
+}
+
+func resourceVolumeAttachmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace, vmName, diskName, err := volumeAttachmentIDParts(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	vm, err := c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).Get(ctx, vmName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	running, err := isVirtualMachineRunning(ctx, c, namespace, vmName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	hotPlugged := false
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.Name == diskName && volume.PersistentVolumeClaim != nil {
+			hotPlugged = volume.PersistentVolumeClaim.Hotpluggable
+		}
+	}
+
+	var diags diag.Diagnostics
+	if running && hotPlugged {
+		body, err := json.Marshal(&kubevirtv1.RemoveVolumeOptions{Name: diskName})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if err = c.KubeVirtSubresourceClient.Put().Namespace(namespace).Resource(constants.ResourceVirtualMachine).SubResource(constants.SubresourceRemoveVolume).Name(vmName).Body(body).Do(ctx).Error(); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to unplug disk %s from virtual machine %s/%s: %w", diskName, namespace, vmName, err))
+		}
+		stateConf := &retry.StateChangeConf{
+			Pending:    []string{constants.StateVolumeAttachmentAttached, constants.StateVolumeAttachmentAttaching, constants.StateVolumeAttachmentDetaching},
+			Target:     []string{constants.StateCommonRemoved},
+			Refresh:    resourceVolumeAttachmentRefresh(ctx, c, namespace, vmName, diskName),
+			Timeout:    d.Timeout(schema.TimeoutDelete),
+			Delay:      1 * time.Second,
+			MinTimeout: 3 * time.Second,
+		}
+		if _, err = stateConf.WaitForStateContext(ctx); err != nil {
+			return diag.FromErr(err)
+		}
+	} else if running && hasVolume(vm, diskName) {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Virtual machine restart required",
+			Detail:   fmt.Sprintf("Disk %s is removed from the running virtual machine %s/%s, it is detached when the virtual machine restarts.", diskName, namespace, vmName),
+		})
+	}
+
+	if err = updateVirtualMachine(ctx, c, namespace, vmName, func(vm *kubevirtv1.VirtualMachine) error {
+		setVolumeAttachment(vm, diskName, false)
+		disks := vm.Spec.Template.Spec.Domain.Devices.Disks[:0]
+		for _, disk := range vm.Spec.Template.Spec.Domain.Devices.Disks {
+			if disk.Name != diskName {
+				disks = append(disks, disk)
+			}
+		}
+		vm.Spec.Template.Spec.Domain.Devices.Disks = disks
+		volumes := vm.Spec.Template.Spec.Volumes[:0]
+		for _, volume := range vm.Spec.Template.Spec.Volumes {
+			if volume.Name != diskName {
+				volumes = append(volumes, volume)
+			}
+		}
+		vm.Spec.Template.Spec.Volumes = volumes
+		return nil
+	}); err != nil && !apierrors.IsNotFound(err) {
+		return append(diags, diag.FromErr(err)...)
+	}
+	volumeName := d.Get(constants.FieldVolumeAttachmentVolumeName).(string)
+	if err = util.SetVolumeLastAttachedVM(ctx, c, namespace, volumeName, vmName); err != nil {
+		tflog.Warn(ctx, "failed to record the last attached virtual machine of the volume", map[string]interface{}{
+			"volume": namespace + "/" + volumeName,
+			"error":  err.Error(),
+		})
+	}
+
+	d.SetId("")
+	return diags
+}
+
+// resourceVolumeAttachmentRefresh returns the state of the disk, which is
+// removed when it is neither in the virtual machine nor in its instance.
+func resourceVolumeAttachmentRefresh(ctx context.Context, c *client.Client, namespace, vmName, diskName string) retry.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		vm, err := c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).Get(ctx, vmName, metav1.GetOptions{})
+		if err != nil {
+			return vm, constants.StateCommonError, err
+		}
+		vmi, err := getVirtualMachineInstance(ctx, c, namespace, vmName)
+		if err != nil {
+			return vm, constants.StateCommonError, err
+		}
+		if !hasVolume(vm, diskName) {
+			if vmi != nil {
+				for _, volumeStatus := range vmi.Status.VolumeStatus {
+					if volumeStatus.Name == diskName {
+						return vm, constants.StateVolumeAttachmentDetaching, nil
+					}
+				}
+			}
+			if importer.GetVolumeAttachments(vm.Annotations)[diskName] && len(vm.Status.VolumeRequests) > 0 {
+				return vm, constants.StateVolumeAttachmentAttaching, nil
+			}
+			return vm, constants.StateCommonRemoved, nil
+		}
+		stateGetter, err := importer.ResourceVolumeAttachmentStateGetter(vm, vmi, diskName)
+		if err != nil {
+			return vm, constants.StateCommonError, err
+		}
+		return vm, stateGetter.States[constants.FieldCommonState].(string), nil
+	}
+}
+
+// updateVirtualMachine applies the mutation to the latest virtual machine,
+// and retries on conflicts with other updates.
+func updateVirtualMachine(ctx context.Context, c *client.Client, namespace, name string, mutate func(*kubevirtv1.VirtualMachine) error) error {
+	return kuberetry.RetryOnConflict(kuberetry.DefaultRetry, func() error {
+		vm, err := c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err = mutate(vm); err != nil {
+			return err
+		}
+		_, err = c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).Update(ctx, vm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// rollbackVolumeAttachment removes the disk from the volume attachments after
+// a failed hot plug, and verifies that the virtual machine has neither the
+// disk nor a request to add it.
+func rollbackVolumeAttachment(ctx context.Context, c *client.Client, namespace, vmName, diskName string) error {
+	ctx, cancel := util.CleanupContext(ctx)
+	defer cancel()
+	if err := updateVirtualMachine(ctx, c, namespace, vmName, func(vm *kubevirtv1.VirtualMachine) error {
+		setVolumeAttachment(vm, diskName, false)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to remove disk %s from the volume attachments of virtual machine %s/%s: %w", diskName, namespace, vmName, err)
+	}
+	vm, err := c.HarvesterClient.KubevirtV1().VirtualMachines(namespace).Get(ctx, vmName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to verify the removal of disk %s from virtual machine %s/%s: %w", diskName, namespace, vmName, err)
+	}
+	if isDiskAttached(vm, diskName) {
+		return fmt.Errorf("disk %s is still attached to virtual machine %s/%s", diskName, namespace, vmName)
+	}
+	return nil
+}
+
+// isDiskAttached reports whether the disk is in the volume attachments, the
+// volumes or the pending volume requests of the virtual machine.
+func isDiskAttached(vm *kubevirtv1.VirtualMachine, diskName string) bool {
+	if importer.GetVolumeAttachments(vm.Annotations)[diskName] || hasVolume(vm, diskName) {
+		return true
+	}
+	for _, request := range vm.Status.VolumeRequests {
+		if request.AddVolumeOptions != nil && request.AddVolumeOptions.Name == diskName {
+			return true
+		}
+	}
+	return false
+}
+
+// setVolumeAttachment adds or removes the disk from the volume attachments
+// annotation of the virtual machine.
+func setVolumeAttachment(vm *kubevirtv1.VirtualMachine, diskName string, attached bool) {
+	attachments := importer.GetVolumeAttachments(vm.Annotations)
+	if attached {
+		attachments[diskName] = true
+	} else {
+		delete(attachments, diskName)
+	}
+	diskNames := make([]string, 0, len(attachments))
+	for name := range attachments {
+		diskNames = append(diskNames, name)
+	}
+	sort.Strings(diskNames)
+	if vm.Annotations == nil {
+		vm.Annotations = map[string]string{}
+	}
+	if len(diskNames) == 0 {
+		delete(vm.Annotations, constants.AnnotationVolumeAttachments)
+		return
+	}
+	vm.Annotations[constants.AnnotationVolumeAttachments] = strings.Join(diskNames, ",")
+}
+
+func hasVolume(vm *kubevirtv1.VirtualMachine, diskName string) bool {
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.Name == diskName {
+			return true
+		}
+	}
+	return false
+}
+
+func getVirtualMachineInstance(ctx context.Context, c *client.Client, namespace, name string) (*kubevirtv1.VirtualMachineInstance, error) {
+	vmi, err := c.HarvesterClient.KubevirtV1().VirtualMachineInstances(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return vmi, nil
+}
+
+func isVirtualMachineRunning(ctx context.Context, c *client.Client, namespace, name string) (bool, error) {
+	vmi, err := getVirtualMachineInstance(ctx, c, namespace, name)
+	if err != nil {
+		return false, err
+	}
+	return vmi != nil && !vmi.IsFinal(), nil
+}
+
+// volumeAttachmentIDParts splits the ID namespace/virtual machine name/disk
+// name of a volume attachment.
+func volumeAttachmentIDParts(id string) (string, string, string, error) {
+	parts := strings.Split(id, helper.IDSep)
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("unexpected ID format (%q), expected %q. ", id, "namespace/virtual_machine_name/disk_name")
+	}
+	return parts[0], parts[1], parts[2], nil
+}
@@ -0,0 +1,143 @@
+package volumeattachment
+
+import (
+	"testing"
+
+	"github.com/harvester/harvester/pkg/builder"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func TestValidateHotPlugBus(t *testing.T) {
+	testCases := []struct {
+		hotPlug     bool
+		bus         string
+		expectError bool
+	}{
+		{hotPlug: true, bus: "", expectError: false},
+		{hotPlug: true, bus: builder.DiskBusScsi, expectError: false},
+		{hotPlug: true, bus: builder.DiskBusVirtio, expectError: false},
+		{hotPlug: true, bus: builder.DiskBusSata, expectError: true},
+		{hotPlug: true, bus: "usb", expectError: true},
+		{hotPlug: false, bus: builder.DiskBusSata, expectError: false},
+	}
+	for _, tc := range testCases {
+		if err := validateHotPlugBus(tc.hotPlug, tc.bus); (err != nil) != tc.expectError {
+			t.Errorf("hot plug %t with bus %q: expected an error %t, got %v", tc.hotPlug, tc.bus, tc.expectError, err)
+		}
+	}
+}
+
+func TestSetVolumeAttachment(t *testing.T) {
+	vm := &kubevirtv1.VirtualMachine{}
+	setVolumeAttachment(vm, "data", true)
+	setVolumeAttachment(vm, "backup", true)
+	setVolumeAttachment(vm, "data", true)
+	if actual := vm.Annotations[constants.AnnotationVolumeAttachments]; actual != "backup,data" {
+		t.Errorf("expected the disks backup,data, got %q", actual)
+	}
+	setVolumeAttachment(vm, "backup", false)
+	if actual := vm.Annotations[constants.AnnotationVolumeAttachments]; actual != "data" {
+		t.Errorf("expected the disk data, got %q", actual)
+	}
+	setVolumeAttachment(vm, "data", false)
+	if _, ok := vm.Annotations[constants.AnnotationVolumeAttachments]; ok {
+		t.Error("expected the annotation to be removed with the last disk")
+	}
+}
+
+func TestIsDiskAttached(t *testing.T) {
+	newVM := func() *kubevirtv1.VirtualMachine {
+		return &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+			Spec: kubevirtv1.VirtualMachineSpec{
+				Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{},
+			},
+		}
+	}
+	testCases := []struct {
+		name        string
+		vm          func() *kubevirtv1.VirtualMachine
+		expectation bool
+	}{
+		{
+			name:        "rolled back",
+			vm:          newVM,
+			expectation: false,
+		},
+		{
+			name: "in the volume attachments",
+			vm: func() *kubevirtv1.VirtualMachine {
+				vm := newVM()
+				vm.Annotations[constants.AnnotationVolumeAttachments] = "other,data"
+				return vm
+			},
+			expectation: true,
+		},
+		{
+			name: "in the volumes",
+			vm: func() *kubevirtv1.VirtualMachine {
+				vm := newVM()
+				vm.Spec.Template.Spec.Volumes = []kubevirtv1.Volume{{
+					Name: "data",
+					VolumeSource: kubevirtv1.VolumeSource{
+						PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+							PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+							Hotpluggable:                      true,
+						},
+					},
+				}}
+				return vm
+			},
+			expectation: true,
+		},
+		{
+			name: "requested to be added",
+			vm: func() *kubevirtv1.VirtualMachine {
+				vm := newVM()
+				vm.Status.VolumeRequests = []kubevirtv1.VirtualMachineVolumeRequest{
+					{RemoveVolumeOptions: &kubevirtv1.RemoveVolumeOptions{Name: "data"}},
+					{AddVolumeOptions: &kubevirtv1.AddVolumeOptions{Name: "data"}},
+				}
+				return vm
+			},
+			expectation: true,
+		},
+		{
+			name: "only requested to be removed",
+			vm: func() *kubevirtv1.VirtualMachine {
+				vm := newVM()
+				vm.Status.VolumeRequests = []kubevirtv1.VirtualMachineVolumeRequest{
+					{RemoveVolumeOptions: &kubevirtv1.RemoveVolumeOptions{Name: "data"}},
+				}
+				return vm
+			},
+			expectation: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isDiskAttached(tc.vm(), "data"); actual != tc.expectation {
+				t.Errorf("expected %t, got %t", tc.expectation, actual)
+			}
+		})
+	}
+}
+
+func TestVolumeAttachmentIDParts(t *testing.T) {
+	namespace, vmName, diskName, err := volumeAttachmentIDParts("default/vm/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace != "default" || vmName != "vm" || diskName != "data" {
+		t.Errorf("expected default, vm and data, got %s, %s and %s", namespace, vmName, diskName)
+	}
+	for _, id := range []string{"default/vm", "default/vm/data/other"} {
+		if _, _, _, err = volumeAttachmentIDParts(id); err == nil {
+			t.Errorf("expected an error for the ID %s", id)
+		}
+	}
+}
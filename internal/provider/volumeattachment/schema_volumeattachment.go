@@ -0,0 +1,60 @@
+package volumeattachment
+
+import (
+	"github.com/harvester/harvester/pkg/builder"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func Schema() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		constants.FieldVolumeAttachmentVirtualMachineName: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: util.IsValidName,
+		},
+		constants.FieldVolumeAttachmentVolumeName: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: util.IsValidName,
+		},
+		constants.FieldVolumeAttachmentDiskName: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ForceNew:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "The name of the disk in the virtual machine, defaults to the volume name",
+		},
+		constants.FieldVolumeAttachmentBus: {
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+			ForceNew: true,
+			ValidateFunc: validation.StringInSlice([]string{
+				builder.DiskBusVirtio,
+				builder.DiskBusSata,
+				builder.DiskBusScsi,
+			}, false),
+			Description: "Defaults to `scsi` for hot plugged volumes, which can only use `scsi` or `virtio`, and to `virtio` otherwise",
+		},
+		constants.FieldVolumeAttachmentHotPlug: {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			ForceNew:    true,
+			Description: "Hot plug the volume, which attaches and detaches it without a restart of the virtual machine. A volume can only be attached to a running virtual machine with hot plug",
+		},
+		constants.FieldCommonState: {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+	util.NamespaceSchemaWrap(s, false)
+	return s
+}
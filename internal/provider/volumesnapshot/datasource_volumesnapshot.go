@@ -0,0 +1,34 @@
+package volumesnapshot
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func DataSourceVolumeSnapshot() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceVolumeSnapshotRead,
+		Schema:      DataSourceSchema(),
+	}
+}
+
+func dataSourceVolumeSnapshotRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace := d.Get(constants.FieldCommonNamespace).(string)
+	name := d.Get(constants.FieldCommonName).(string)
+	obj, err := c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(resourceVolumeSnapshotImport(d, obj))
+}
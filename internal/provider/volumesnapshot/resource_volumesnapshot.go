@@ -0,0 +1,184 @@
+package volumesnapshot
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+	"github.com/harvester/terraform-provider-harvester/pkg/importer"
+)
+
+func ResourceVolumeSnapshot() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceVolumeSnapshotCreate,
+		ReadContext:   resourceVolumeSnapshotRead,
+		DeleteContext: resourceVolumeSnapshotDelete,
+		UpdateContext: resourceVolumeSnapshotUpdate,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: Schema(),
+		Timeouts: &schema.ResourceTimeout{
+			Create:  schema.DefaultTimeout(10 * time.Minute),
+			Read:    schema.DefaultTimeout(2 * time.Minute),
+			Update:  schema.DefaultTimeout(2 * time.Minute),
+			Delete:  schema.DefaultTimeout(5 * time.Minute),
+			Default: schema.DefaultTimeout(2 * time.Minute),
+		},
+	}
+}
+
+func resourceVolumeSnapshotCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace := d.Get(constants.FieldCommonNamespace).(string)
+	name := d.Get(constants.FieldCommonName).(string)
+	toCreate, err := util.ResourceConstruct(ctx, d, Creator(namespace, name))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	volumeSnapshot := toCreate.(*unstructured.Unstructured)
+	if d.Get(constants.FieldVolumeSnapshotVolumeSnapshotClassName).(string) == "" {
+		className, err := volumeSnapshotClassName(ctx, c, namespace, d.Get(constants.FieldVolumeSnapshotVolumeName).(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if className != "" {
+			if err = unstructured.SetNestedField(volumeSnapshot.Object, className, "spec", "volumeSnapshotClassName"); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+	if _, err = c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Create(ctx, volumeSnapshot, metav1.CreateOptions{}); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(helper.BuildID(namespace, name))
+
+	if err = util.WaitForVolumeSnapshotReady(ctx, c, namespace, name); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceVolumeSnapshotRead(ctx, d, meta)
+}
+
+func resourceVolumeSnapshotUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace, name, err := helper.IDParts(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	obj, err := c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	toUpdate, err := util.ResourceConstruct(ctx, d, Updater(obj))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if _, err = c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Update(ctx, toUpdate.(*unstructured.Unstructured), metav1.UpdateOptions{}); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceVolumeSnapshotRead(ctx, d, meta)
+}
+
+func resourceVolumeSnapshotRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace, name, err := helper.IDParts(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	obj, err := c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(resourceVolumeSnapshotImport(d, obj))
+}
+
+func resourceVolumeSnapshotDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c, err := meta.(*config.Config).K8sClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	namespace, name, err := helper.IDParts(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err = c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+
+	stateConf := &retry.StateChangeConf{
+		Pending:    []string{constants.StateCommonActive},
+		Target:     []string{constants.StateCommonRemoved},
+		Refresh:    resourceVolumeSnapshotRefresh(ctx, c, namespace, name),
+		Timeout:    d.Timeout(schema.TimeoutDelete),
+		Delay:      1 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+	if _, err = stateConf.WaitForStateContext(ctx); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func resourceVolumeSnapshotImport(d *schema.ResourceData, obj *unstructured.Unstructured) error {
+	stateGetter, err := importer.ResourceVolumeSnapshotStateGetter(obj)
+	if err != nil {
+		return err
+	}
+	return util.ResourceStatesSet(d, stateGetter)
+}
+
+func resourceVolumeSnapshotRefresh(ctx context.Context, c *client.Client, namespace, name string) retry.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		obj, err := c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return obj, constants.StateCommonRemoved, nil
+			}
+			return obj, constants.StateCommonError, err
+		}
+		return obj, constants.StateCommonActive, nil
+	}
+}
+
+// volumeSnapshotClassName returns the volume snapshot class Harvester uses
+// for the storage class of the volume.
+func volumeSnapshotClassName(ctx context.Context, c *client.Client, namespace, volumeName string) (string, error) {
+	pvc, err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, volumeName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if pvc.Spec.StorageClassName == nil {
+		return "", nil
+	}
+	return util.VolumeSnapshotClassName(ctx, c, *pvc.Spec.StorageClassName)
+}
@@ -0,0 +1,79 @@
+package volumesnapshot
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+var (
+	_ util.Constructor = &Constructor{}
+)
+
+// Constructor builds the VolumeSnapshot, which has no typed client, so the
+// metadata is processed apart and set on the object in Result.
+type Constructor struct {
+	VolumeSnapshot *unstructured.Unstructured
+	Labels         map[string]string
+	Annotations    map[string]string
+}
+
+func (c *Constructor) Setup() util.Processors {
+	processors := util.NewProcessors().
+		Tags(&c.Labels).
+		Labels(&c.Labels).
+		Description(&c.Annotations).
+		DeletionProtection(&c.Annotations)
+
+	customProcessors := []util.Processor{
+		{
+			Field: constants.FieldVolumeSnapshotVolumeName,
+			Parser: func(i interface{}) error {
+				return unstructured.SetNestedField(c.VolumeSnapshot.Object, i.(string), "spec", "source", "persistentVolumeClaimName")
+			},
+			Required: true,
+		},
+		{
+			Field: constants.FieldVolumeSnapshotVolumeSnapshotClassName,
+			Parser: func(i interface{}) error {
+				return unstructured.SetNestedField(c.VolumeSnapshot.Object, i.(string), "spec", "volumeSnapshotClassName")
+			},
+		},
+	}
+	return append(processors, customProcessors...)
+}
+
+func (c *Constructor) Validate() error {
+	return nil
+}
+
+func (c *Constructor) Result() (interface{}, error) {
+	c.VolumeSnapshot.SetLabels(c.Labels)
+	c.VolumeSnapshot.SetAnnotations(c.Annotations)
+	return c.VolumeSnapshot, nil
+}
+
+func newVolumeSnapshotConstructor(volumeSnapshot *unstructured.Unstructured) util.Constructor {
+	annotations := volumeSnapshot.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	labels := volumeSnapshot.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return &Constructor{
+		VolumeSnapshot: volumeSnapshot,
+		Labels:         labels,
+		Annotations:    annotations,
+	}
+}
+
+func Creator(namespace, name string) util.Constructor {
+	return newVolumeSnapshotConstructor(util.NewVolumeSnapshot(namespace, name, "", ""))
+}
+
+func Updater(volumeSnapshot *unstructured.Unstructured) util.Constructor {
+	return newVolumeSnapshotConstructor(volumeSnapshot)
+}
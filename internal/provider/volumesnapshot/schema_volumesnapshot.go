@@ -0,0 +1,48 @@
+package volumesnapshot
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func Schema() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		constants.FieldVolumeSnapshotVolumeName: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "The name of the volume to take a snapshot of, in the namespace of the snapshot",
+		},
+		constants.FieldVolumeSnapshotVolumeSnapshotClassName: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ForceNew:     true,
+			ValidateFunc: util.IsValidName,
+			Description:  "Defaults to the volume snapshot class of the storage class of the volume, from the setting `csi-driver-config`",
+		},
+		constants.FieldVolumeSnapshotReadyToUse: {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		constants.FieldVolumeSnapshotRestoreSize: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The minimum size of a volume restored from the snapshot",
+		},
+		constants.FieldVolumeSnapshotCreationTime: {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+	util.NamespacedSchemaWrap(s, false)
+	util.DeletionProtectionSchemaWrap(s)
+	return s
+}
+
+func DataSourceSchema() map[string]*schema.Schema {
+	return util.DataSourceSchemaWrap(Schema())
+}
@@ -33,6 +33,7 @@ resource harvester_bootstrap "%s" {
 	%s = "%s"
 	%s = "%s"
 	%s = "%s"
+	%s = true
 }
 `
 )
@@ -43,6 +44,7 @@ func buildBootstrapConfig(name, url, initialPassword, password string) string {
 		constants.FieldBootstrapInitialPassword, initialPassword,
 		constants.FieldBootstrapPassword, password,
 		constants.FieldBootstrapKubeConfig, testAccKubeConfigPath,
+		constants.FieldBootstrapInsecure,
 	)
 }
 
@@ -70,7 +72,7 @@ func testAccBootstrapLogin(ctx context.Context, n string) resource.TestCheckFunc
 			return fmt.Errorf("Resource %s not found. ", n)
 		}
 
-		_, _, err := bootstrap.DoUserLogin(testAccBootstrapAPIURL, "admin", testAccBootstrapPassword, 600, "This is test", "", true)
+		_, _, err := bootstrap.DoUserLogin(testAccBootstrapAPIURL, "admin", testAccBootstrapPassword, 600, "This is test", "", "", true)
 		return err
 	}
 }
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+)
+
+const (
+	testAccLonghornRecurringJobName         = "test-acc-foo-job"
+	testAccLonghornRecurringJobResourceName = constants.ResourceTypeLonghornRecurringJob + "." + testAccLonghornRecurringJobName
+	testAccLonghornRecurringJobGroup        = "test-acc-foo-group"
+	testAccVolumeGroupedName                = "test-acc-foo-grouped"
+	testAccVolumeGroupedResourceName        = constants.ResourceTypeVolume + "." + testAccVolumeGroupedName
+
+	testAccLonghornRecurringJobConfigTemplate = `
+resource %s "%s" {
+	name   = "%s"
+	task   = "snapshot"
+	cron   = "%s"
+	retain = 2
+	groups = ["%s"]
+}
+
+resource %s "%s" {
+	name                 = "%s"
+	size                 = "%s"
+	recurring_job_groups = ["%s"]
+}
+`
+)
+
+func buildLonghornRecurringJobConfig(cron string) string {
+	return fmt.Sprintf(testAccLonghornRecurringJobConfigTemplate,
+		constants.ResourceTypeLonghornRecurringJob, testAccLonghornRecurringJobName, testAccLonghornRecurringJobName,
+		cron, testAccLonghornRecurringJobGroup,
+		constants.ResourceTypeVolume, testAccVolumeGroupedName, testAccVolumeGroupedName, testAccVolumeSize,
+		testAccLonghornRecurringJobGroup)
+}
+
+func TestAccLonghornRecurringJob_basic(t *testing.T) {
+	ctx := context.Background()
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckLonghornRecurringJobDestroy(ctx),
+		Steps: []resource.TestStep{
+			{
+				Config: buildLonghornRecurringJobConfig("0 2 * * *"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(testAccLonghornRecurringJobResourceName, constants.FieldLonghornRecurringJobCron, "0 2 * * *"),
+					resource.TestCheckResourceAttr(testAccLonghornRecurringJobResourceName, constants.FieldLonghornRecurringJobRetain, "2"),
+					resource.TestCheckResourceAttr(testAccLonghornRecurringJobResourceName, constants.FieldLonghornRecurringJobGroups+".0", testAccLonghornRecurringJobGroup),
+					resource.TestCheckResourceAttr(testAccVolumeGroupedResourceName, constants.FieldVolumeRecurringJobGroups+".#", "1"),
+					resource.TestCheckResourceAttr(testAccVolumeGroupedResourceName, constants.FieldCommonLabels+".%", "0"),
+				),
+			},
+			{
+				Config: buildLonghornRecurringJobConfig("0 3 * * *"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(testAccLonghornRecurringJobResourceName, constants.FieldLonghornRecurringJobCron, "0 3 * * *"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckLonghornRecurringJobDestroy(ctx context.Context) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != constants.ResourceTypeLonghornRecurringJob {
+				continue
+			}
+
+			c, err := testAccProvider.Meta().(*config.Config).K8sClient()
+			if err != nil {
+				return err
+			}
+			_, name, err := helper.IDParts(rs.Primary.ID)
+			if err != nil {
+				return err
+			}
+
+			recurringJobStateRefreshFunc := getResourceStateRefreshFunc(func() (interface{}, error) {
+				return c.DynamicClient.Resource(util.LonghornRecurringJobGVR).Namespace(constants.LonghornNamespace).Get(ctx, name, metav1.GetOptions{})
+			})
+			stateConf := getStateChangeConf(recurringJobStateRefreshFunc)
+			if _, err = stateConf.WaitForStateContext(ctx); err != nil {
+				return fmt.Errorf(
+					"[ERROR] waiting for Longhorn recurring job (%s) to be removed: %s", rs.Primary.ID, err)
+			}
+		}
+		return nil
+	}
+}
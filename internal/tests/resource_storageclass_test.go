@@ -27,6 +27,21 @@ resource %s "%s" {
 	parameters = {
 	}
 }
+`
+	testAccStorageClassLonghornConfigTemplate = `
+resource %s "%s" {
+	name = "%s"
+	longhorn {
+		number_of_replicas    = 2
+		stale_replica_timeout = 30
+		disk_selector         = ["ssd"]
+		data_locality         = "best-effort"
+		recurring_job_selector {
+			name     = "default"
+			is_group = true
+		}
+	}
+}
 `
 )
 
@@ -59,6 +74,47 @@ func TestAccStorageClass_basic(t *testing.T) {
 	})
 }
 
+func TestAccStorageClass_longhorn(t *testing.T) {
+	var (
+		storageClass = &storagev1.StorageClass{}
+		ctx          = context.Background()
+	)
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckStorageClassDestroy(ctx),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccStorageClassLonghornConfigTemplate, constants.ResourceTypeStorageClass,
+					testAccStorageClassName, testAccStorageClassName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccStorageClassExists(ctx, testAccStorageClassResourceName, storageClass),
+					resource.TestCheckResourceAttr(testAccStorageClassResourceName, "parameters.%", "0"),
+					resource.TestCheckResourceAttr(testAccStorageClassResourceName, "longhorn.0.number_of_replicas", "2"),
+					resource.TestCheckResourceAttr(testAccStorageClassResourceName, "longhorn.0.migratable", "true"),
+					resource.TestCheckResourceAttr(testAccStorageClassResourceName, "longhorn.0.recurring_job_selector.0.is_group", "true"),
+					func(s *terraform.State) error {
+						expected := map[string]string{
+							constants.LonghornParameterNumberOfReplicas:     "2",
+							constants.LonghornParameterStaleReplicaTimeout:  "30",
+							constants.LonghornParameterDiskSelector:         "ssd",
+							constants.LonghornParameterDataLocality:         "best-effort",
+							constants.LonghornParameterMigratable:           "true",
+							constants.LonghornParameterRecurringJobSelector: `[{"name":"default","isGroup":true}]`,
+						}
+						for key, value := range expected {
+							if storageClass.Parameters[key] != value {
+								return fmt.Errorf("expected parameter %s to be %q, got %q", key, value, storageClass.Parameters[key])
+							}
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
 func testAccStorageClassExists(ctx context.Context, n string, storageClass *storagev1.StorageClass) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -84,7 +140,9 @@ func testAccStorageClassExists(ctx context.Context, n string, storageClass *stor
 		if err != nil {
 			return err
 		}
-		storageClass = foundStorageClass
+		if storageClass != nil {
+			*storageClass = *foundStorageClass
+		}
 		return nil
 	}
 }
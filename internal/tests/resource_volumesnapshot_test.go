@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/terraform-provider-harvester/internal/config"
+	"github.com/harvester/terraform-provider-harvester/internal/util"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+)
+
+const (
+	testAccVolumeSnapshotName         = "test-acc-foo-snapshot"
+	testAccVolumeSnapshotResourceName = constants.ResourceTypeVolumeSnapshot + "." + testAccVolumeSnapshotName
+	testAccVolumeRestoredName         = "test-acc-foo-restored"
+	testAccVolumeRestoredResourceName = constants.ResourceTypeVolume + "." + testAccVolumeRestoredName
+
+	testAccVolumeSnapshotConfigTemplate = `
+resource %s "%s" {
+	%s = "%s"
+	%s = "%s"
+}
+
+resource %s "%s" {
+	%s = "%s"
+	%s = %s.%s.%s
+}
+
+resource %s "%s" {
+	%s = "%s"
+	%s = "%s"
+	%s = %s.%s
+}
+`
+)
+
+func buildVolumeSnapshotConfig() string {
+	return fmt.Sprintf(testAccVolumeSnapshotConfigTemplate,
+		constants.ResourceTypeVolume, testAccVolumeName,
+		constants.FieldCommonName, testAccVolumeName,
+		constants.FieldVolumeSize, testAccVolumeSize,
+		constants.ResourceTypeVolumeSnapshot, testAccVolumeSnapshotName,
+		constants.FieldCommonName, testAccVolumeSnapshotName,
+		constants.FieldVolumeSnapshotVolumeName, constants.ResourceTypeVolume, testAccVolumeName, constants.FieldCommonName,
+		constants.ResourceTypeVolume, testAccVolumeRestoredName,
+		constants.FieldCommonName, testAccVolumeRestoredName,
+		constants.FieldVolumeSize, testAccVolumeSize,
+		constants.FieldVolumeSourceSnapshot, testAccVolumeSnapshotResourceName, constants.FieldCommonName)
+}
+
+func TestAccVolumeSnapshot_basic(t *testing.T) {
+	ctx := context.Background()
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVolumeSnapshotDestroy(ctx),
+		Steps: []resource.TestStep{
+			{
+				Config: buildVolumeSnapshotConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(testAccVolumeSnapshotResourceName, constants.FieldVolumeSnapshotVolumeName, testAccVolumeName),
+					resource.TestCheckResourceAttr(testAccVolumeSnapshotResourceName, constants.FieldVolumeSnapshotReadyToUse, "true"),
+					resource.TestCheckResourceAttr(testAccVolumeRestoredResourceName, constants.FieldVolumeSourceSnapshot, testAccVolumeSnapshotName),
+					resource.TestCheckResourceAttr(testAccVolumeRestoredResourceName, constants.FieldPhase, "Bound"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVolumeSnapshotDestroy(ctx context.Context) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != constants.ResourceTypeVolumeSnapshot {
+				continue
+			}
+
+			c, err := testAccProvider.Meta().(*config.Config).K8sClient()
+			if err != nil {
+				return err
+			}
+			namespace, name, err := helper.IDParts(rs.Primary.ID)
+			if err != nil {
+				return err
+			}
+
+			volumeSnapshotStateRefreshFunc := getResourceStateRefreshFunc(func() (interface{}, error) {
+				return c.DynamicClient.Resource(util.VolumeSnapshotGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+			})
+			stateConf := getStateChangeConf(volumeSnapshotStateRefreshFunc)
+			if _, err = stateConf.WaitForStateContext(ctx); err != nil {
+				return fmt.Errorf(
+					"[ERROR] waiting for volume snapshot (%s) to be removed: %s", rs.Primary.ID, err)
+			}
+		}
+		return nil
+	}
+}
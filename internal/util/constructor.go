@@ -104,10 +104,32 @@ func (p Processors) Labels(labels *map[string]string) Processors {
 	})
 }
 
+func (p Processors) DeletionProtection(annotations *map[string]string) Processors {
+	delete(*annotations, constants.AnnotationDeletionProtection)
+	return append(p, Processor{
+		Field: constants.FieldCommonDeletionProtection,
+		Parser: func(i interface{}) error {
+			if i.(bool) {
+				*annotations = MapMerge(*annotations, "", map[string]interface{}{
+					constants.AnnotationDeletionProtection: "true",
+				})
+			}
+			return nil
+		},
+	})
+}
+
 func NewProcessors() Processors {
 	return []Processor{}
 }
 
+// AllFields maps tags and labels to the computed fields which hold them merged
+// with the provider defaults, these are set by the provider resource wrapper.
+var AllFields = map[string]string{
+	constants.FieldCommonTags:   constants.FieldCommonTagsAll,
+	constants.FieldCommonLabels: constants.FieldCommonLabelsAll,
+}
+
 func ResourceConstruct(ctx context.Context, d *schema.ResourceData, c Constructor) (interface{}, error) {
 	for _, processor := range c.Setup() {
 		var (
@@ -118,6 +140,11 @@ func ResourceConstruct(ctx context.Context, d *schema.ResourceData, c Constructo
 			value = d.Get(processor.Field)
 		} else {
 			value, ok = d.GetOk(processor.Field)
+			if allField, hasAll := AllFields[processor.Field]; hasAll {
+				if allValue, allOk := d.GetOk(allField); allOk {
+					value, ok = allValue, true
+				}
+			}
 			if !ok {
 				tflog.Info(ctx, fmt.Sprintf("skipping field: %s", processor.Field))
 				continue
@@ -0,0 +1,15 @@
+package util
+
+import (
+	"context"
+	"time"
+)
+
+// cleanupTimeout limits how long the rollback of a failed operation may take.
+const cleanupTimeout = 30 * time.Second
+
+// CleanupContext returns a context to roll back a failed operation, it is not
+// canceled with ctx, which is often done or past its deadline by then.
+func CleanupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+}
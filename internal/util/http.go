@@ -10,6 +10,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -17,13 +18,25 @@ const (
 	maxHTTPRedirect = 5
 )
 
-func DoPost(url, data, cacert string, insecure bool, headers map[string]string) (*http.Response, error) {
+// DoPost sends a POST request, the server certificate is verified against the
+// system CAs and cacert, unless insecure is set. tlsServerName overrides the
+// name the certificate is verified for, e.g. when url uses an IP address.
+func DoPost(url, data, cacert, tlsServerName string, insecure bool, headers map[string]string) (*http.Response, error) {
+	return doRequest(http.MethodPost, url, data, cacert, tlsServerName, insecure, headers)
+}
+
+// DoPut sends a PUT request, like DoPost.
+func DoPut(url, data, cacert, tlsServerName string, insecure bool, headers map[string]string) (*http.Response, error) {
+	return doRequest(http.MethodPut, url, data, cacert, tlsServerName, insecure, headers)
+}
+
+func doRequest(method, url, data, cacert, tlsServerName string, insecure bool, headers map[string]string) (*http.Response, error) {
 	if url == "" {
-		return nil, fmt.Errorf("doing post: URL is nil")
+		return nil, fmt.Errorf("doing %s: URL is nil", strings.ToLower(method))
 	}
 
 	jsonBytes := []byte(data)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBytes))
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonBytes))
 	if err != nil {
 		return nil, err
 	}
@@ -34,31 +47,12 @@ func DoPost(url, data, cacert string, insecure bool, headers map[string]string)
 
 	client := &http.Client{}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure}, //nolint:gosec
-		Proxy:           http.ProxyFromEnvironment,
-	}
-
-	if cacert != "" {
-		// Get the SystemCertPool, continue with an empty pool on error
-		rootCAs, _ := x509.SystemCertPool()
-		if rootCAs == nil {
-			rootCAs = x509.NewCertPool()
-		}
-
-		// Append our cert to the system pool
-		if ok := rootCAs.AppendCertsFromPEM([]byte(cacert)); !ok {
-			log.Println("No certs appended, using system certs only")
-		}
-		transport.TLSClientConfig.RootCAs = rootCAs
-	}
-
-	client.Transport = transport
+	client.Transport = newTransport(cacert, tlsServerName, insecure)
 
 	return client.Do(req) //nolint:gosec
 }
 
-func DoGet(url, username, password, token, cacert string, insecure bool) (*http.Response, error) {
+func DoGet(url, username, password, token, cacert, tlsServerName string, insecure bool) (*http.Response, error) {
 	start := time.Now()
 
 	if url == "" {
@@ -82,9 +76,50 @@ func DoGet(url, username, password, token, cacert string, insecure bool) (*http.
 		},
 	}
 
+	client.Transport = newTransport(cacert, tlsServerName, insecure)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("doing get: %v", err)
+	}
+	if len(token) > 0 {
+		req.Header.Add("Authorization", "Bearer "+token)
+	} else if len(username) > 0 && len(password) > 0 {
+		s := username + ":" + password
+		req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(s)))
+	}
+	// Timings recorded as part of internal metrics
+	log.Println("Time to get req: ", float64((time.Since(start))/time.Millisecond), " ms")
+
+	return client.Do(req) //nolint:gosec
+}
+
+// DoDelete sends a DELETE request authenticated with the bearer token.
+func DoDelete(url, token, cacert, tlsServerName string, insecure bool) (*http.Response, error) {
+	if url == "" {
+		return nil, fmt.Errorf("doing delete: URL is nil")
+	}
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := &http.Client{
+		Timeout:   60 * time.Second,
+		Transport: newTransport(cacert, tlsServerName, insecure),
+	}
+	return client.Do(req) //nolint:gosec
+}
+
+func newTransport(cacert, tlsServerName string, insecure bool) *http.Transport {
 	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure}, //nolint:gosec
-		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{ //nolint:gosec
+			InsecureSkipVerify: insecure,
+			ServerName:         tlsServerName,
+		},
+		Proxy: http.ProxyFromEnvironment,
 	}
 
 	if cacert != "" {
@@ -100,22 +135,7 @@ func DoGet(url, username, password, token, cacert string, insecure bool) (*http.
 		}
 		transport.TLSClientConfig.RootCAs = rootCAs
 	}
-	client.Transport = transport
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("doing get: %v", err)
-	}
-	if len(token) > 0 {
-		req.Header.Add("Authorization", "Bearer "+token)
-	} else if len(username) > 0 && len(password) > 0 {
-		s := username + ":" + password
-		req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(s)))
-	}
-	// Timings recorded as part of internal metrics
-	log.Println("Time to get req: ", float64((time.Since(start))/time.Millisecond), " ms")
-
-	return client.Do(req) //nolint:gosec
+	return transport
 }
 
 // DoPostWithTransport sends a context-aware POST request using the given transport.
@@ -135,3 +155,21 @@ func DoPostWithTransport(ctx context.Context, url string, body io.Reader, conten
 	client := &http.Client{Transport: transport}
 	return client.Do(req) //nolint:gosec // G107: URL comes from trusted provider configuration
 }
+
+// DoGetWithHeaders sends a context-aware GET request with the headers, the
+// server certificate is verified against the system CAs and cacert. It has no
+// client timeout, so it can be used for large downloads.
+func DoGetWithHeaders(ctx context.Context, url string, headers map[string]string, cacert string) (*http.Response, error) {
+	if url == "" {
+		return nil, fmt.Errorf("doing get: URL is nil")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	client := &http.Client{Transport: newTransport(cacert, "", false)}
+	return client.Do(req) //nolint:gosec // G107: URL comes from the Terraform configuration
+}
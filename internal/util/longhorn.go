@@ -0,0 +1,34 @@
+package util
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+// LonghornRecurringJobGVR is the Longhorn RecurringJob, which is used through
+// the dynamic client since there is no Longhorn clientset.
+var LonghornRecurringJobGVR = schema.GroupVersionResource{
+	Group:    constants.LonghornAPIGroup,
+	Version:  "v1beta2",
+	Resource: "recurringjobs",
+}
+
+// NewLonghornRecurringJob returns an empty RecurringJob in the Longhorn
+// namespace, Longhorn requires the name in the spec to be the object name.
+func NewLonghornRecurringJob(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": LonghornRecurringJobGVR.GroupVersion().String(),
+			"kind":       constants.LonghornRecurringJobKind,
+			"metadata": map[string]interface{}{
+				"namespace": constants.LonghornNamespace,
+				"name":      name,
+			},
+			"spec": map[string]interface{}{
+				"name": name,
+			},
+		},
+	}
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -10,11 +11,17 @@ import (
 )
 
 func NamespacedSchemaWrap(s map[string]*schema.Schema, system bool) {
+	NonNamespacedSchemaWrap(s)
+	NamespaceSchemaWrap(s, system)
+}
+
+// NamespaceSchemaWrap adds only the namespace, for namespaced resources which
+// have no name of their own.
+func NamespaceSchemaWrap(s map[string]*schema.Schema, system bool) {
 	var namespace = constants.NamespaceDefault
 	if system {
 		namespace = constants.NamespaceHarvesterSystem
 	}
-	NonNamespacedSchemaWrap(s)
 	s[constants.FieldCommonNamespace] = &schema.Schema{
 		Type:         schema.TypeString,
 		ForceNew:     true,
@@ -55,6 +62,17 @@ func NonNamespacedSchemaWrap(s map[string]*schema.Schema) {
 	}
 }
 
+// DeletionProtectionSchemaWrap adds the deletion_protection field, which is
+// enforced by the provider for every resource having it.
+func DeletionProtectionSchemaWrap(s map[string]*schema.Schema) {
+	s[constants.FieldCommonDeletionProtection] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Prevent the resource from being deleted or replaced. It is also set as the annotation `" + constants.AnnotationDeletionProtection + "`",
+	}
+}
+
 func IsValidName(i interface{}, k string) ([]string, []error) {
 	v, ok := i.(string)
 	if !ok {
@@ -68,6 +86,19 @@ func IsValidName(i interface{}, k string) ([]string, []error) {
 	return nil, nil
 }
 
+func IsPositiveDuration(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %q to be string", k)}
+	}
+
+	if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+		return nil, []error{fmt.Errorf("expected %q to be a positive duration, e.g. 1m or 720h, got %q", k, v)}
+	}
+
+	return nil, nil
+}
+
 func DataSourceSchemaWrap(s map[string]*schema.Schema) map[string]*schema.Schema {
 	for k, v := range s {
 		if k == constants.FieldCommonName || k == constants.FieldCommonNamespace {
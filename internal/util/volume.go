@@ -0,0 +1,132 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuberetry "k8s.io/client-go/util/retry"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+// WaitForVolumeBound polls the PVC until it is bound, a PVC which doesn't
+// exist yet is waited for as well. It doesn't wait for the PVCs of storage
+// classes which bind volumes on the first consumer.
+func WaitForVolumeBound(ctx context.Context, c *client.Client, namespace, name string) error {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	checkedStorageClass := false
+	for {
+		pvc, err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return fmt.Errorf("failed to get volume %s/%s: %w", namespace, name, err)
+		case pvc.Status.Phase == corev1.ClaimBound:
+			return nil
+		case pvc.Status.Phase == corev1.ClaimLost:
+			return fmt.Errorf("volume %s/%s is lost", namespace, name)
+		case !checkedStorageClass && pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "":
+			storageClass, err := c.StorageClassClient.StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get the storage class of volume %s/%s: %w", namespace, name, err)
+			}
+			if storageClass.VolumeBindingMode != nil && *storageClass.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+				return nil
+			}
+			checkedStorageClass = true
+		}
+		tflog.Debug(ctx, "waiting for the volume to be bound", map[string]interface{}{
+			"volume": namespace + "/" + name,
+		})
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("volume %s/%s is not bound after waiting: %w", namespace, name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewVolumeDataSource returns the data source of a PVC which is cloned from
+// the PVC.
+func NewVolumeDataSource(name string) *corev1.TypedLocalObjectReference {
+	return &corev1.TypedLocalObjectReference{
+		Kind: "PersistentVolumeClaim",
+		Name: name,
+	}
+}
+
+// CheckVolumeCloneSource checks that the PVC can be cloned from the source
+// PVC in its namespace. A CSI clone must have the storage class and volume
+// mode of the source, and at least its size. The storage class, volume mode
+// and size which are not set on the PVC are taken from the source.
+func CheckVolumeCloneSource(ctx context.Context, c *client.Client, pvc *corev1.PersistentVolumeClaim, sourceName string) error {
+	source, err := c.KubeClient.CoreV1().PersistentVolumeClaims(pvc.Namespace).Get(ctx, sourceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the source volume %s/%s: %w", pvc.Namespace, sourceName, err)
+	}
+	if source.Status.Phase != corev1.ClaimBound {
+		return fmt.Errorf("the source volume %s/%s is not bound", pvc.Namespace, sourceName)
+	}
+
+	var sourceStorageClassName string
+	if source.Spec.StorageClassName != nil {
+		sourceStorageClassName = *source.Spec.StorageClassName
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		pvc.Spec.StorageClassName = source.Spec.StorageClassName
+	} else if *pvc.Spec.StorageClassName != sourceStorageClassName {
+		return fmt.Errorf("the %s of a clone must be the storage class %q of the source volume %s/%s",
+			constants.FieldVolumeStorageClassName, sourceStorageClassName, pvc.Namespace, sourceName)
+	}
+
+	if pvc.Spec.VolumeMode == nil {
+		pvc.Spec.VolumeMode = source.Spec.VolumeMode
+	} else if source.Spec.VolumeMode != nil && *pvc.Spec.VolumeMode != *source.Spec.VolumeMode {
+		return fmt.Errorf("the %s of a clone must be the volume mode %q of the source volume %s/%s",
+			constants.FieldVolumeMode, *source.Spec.VolumeMode, pvc.Namespace, sourceName)
+	}
+
+	sourceSize := source.Spec.Resources.Requests[corev1.ResourceStorage]
+	if pvc.Spec.Resources.Requests == nil {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	if size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; !ok || size.IsZero() {
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = sourceSize
+	} else if size.Cmp(sourceSize) < 0 {
+		return fmt.Errorf("the %s %s of a clone is smaller than the size %s of the source volume %s/%s",
+			constants.FieldVolumeSize, size.String(), sourceSize.String(), pvc.Namespace, sourceName)
+	}
+
+	pvc.Spec.DataSource = NewVolumeDataSource(sourceName)
+	return nil
+}
+
+// SetVolumeLastAttachedVM records the virtual machine on the PVC which is kept
+// when the virtual machine, or its attachment, is removed.
+func SetVolumeLastAttachedVM(ctx context.Context, c *client.Client, namespace, name, vmName string) error {
+	return kuberetry.RetryOnConflict(kuberetry.DefaultRetry, func() error {
+		pvc, err := c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if pvc.Annotations == nil {
+			pvc.Annotations = map[string]string{}
+		}
+		pvc.Annotations[constants.AnnotationVolumeLastAttachedVM] = ref.Construct(namespace, vmName)
+		_, err = c.KubeClient.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, pvc, metav1.UpdateOptions{})
+		return err
+	})
+}
@@ -0,0 +1,173 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+)
+
+// newTestVolume returns a PVC of the storage class longhorn, an empty
+// volumeMode isn't set.
+func newTestVolume(name string, phase corev1.PersistentVolumeClaimPhase, volumeMode corev1.PersistentVolumeMode, size string) corev1.PersistentVolumeClaim {
+	pvc := corev1.PersistentVolumeClaim{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: ptr.To("longhorn"),
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+	if volumeMode != "" {
+		pvc.Spec.VolumeMode = ptr.To(volumeMode)
+	}
+	return pvc
+}
+
+// newTestVolumeClient returns a client of an API which only serves the PVCs
+// of the namespace default.
+func newTestVolumeClient(t *testing.T, pvcs ...corev1.PersistentVolumeClaim) *client.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		for _, pvc := range pvcs {
+			if r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/default/persistentvolumeclaims/"+pvc.Name {
+				_ = json.NewEncoder(w).Encode(pvc)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&metav1.Status{Status: metav1.StatusFailure, Code: http.StatusNotFound, Reason: metav1.StatusReasonNotFound})
+	}))
+	t.Cleanup(server.Close)
+	c, err := client.NewClientForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCheckVolumeCloneSource(t *testing.T) {
+	c := newTestVolumeClient(t,
+		newTestVolume("data", corev1.ClaimBound, corev1.PersistentVolumeBlock, "10Gi"),
+		newTestVolume("pending", corev1.ClaimPending, corev1.PersistentVolumeBlock, "10Gi"),
+		newTestVolume("legacy", corev1.ClaimBound, "", "10Gi"),
+	)
+
+	testCases := []struct {
+		name               string
+		source             string
+		storageClassName   *string
+		volumeMode         corev1.PersistentVolumeMode
+		size               string
+		expectStorageClass string
+		expectVolumeMode   corev1.PersistentVolumeMode
+		expectSize         string
+		expectError        string
+	}{
+		{
+			name:               "defaults of the source",
+			source:             "data",
+			expectStorageClass: "longhorn",
+			expectVolumeMode:   corev1.PersistentVolumeBlock,
+			expectSize:         "10Gi",
+		},
+		{
+			name:               "empty storage class",
+			source:             "data",
+			storageClassName:   ptr.To(""),
+			volumeMode:         corev1.PersistentVolumeBlock,
+			size:               "20Gi",
+			expectStorageClass: "longhorn",
+			expectVolumeMode:   corev1.PersistentVolumeBlock,
+			expectSize:         "20Gi",
+		},
+		{
+			name:               "source without volume mode",
+			source:             "legacy",
+			volumeMode:         corev1.PersistentVolumeFilesystem,
+			expectStorageClass: "longhorn",
+			expectVolumeMode:   corev1.PersistentVolumeFilesystem,
+			expectSize:         "10Gi",
+		},
+		{
+			name:        "smaller than the source",
+			source:      "data",
+			size:        "5Gi",
+			expectError: "is smaller than the size 10Gi of the source volume default/data",
+		},
+		{
+			name:             "other storage class",
+			source:           "data",
+			storageClassName: ptr.To("longhorn-ssd"),
+			expectError:      `must be the storage class "longhorn" of the source volume default/data`,
+		},
+		{
+			name:        "other volume mode",
+			source:      "data",
+			volumeMode:  corev1.PersistentVolumeFilesystem,
+			expectError: `must be the volume mode "Block" of the source volume default/data`,
+		},
+		{
+			name:        "unbound source",
+			source:      "pending",
+			expectError: "the source volume default/pending is not bound",
+		},
+		{
+			name:        "missing source",
+			source:      "missing",
+			expectError: "failed to get the source volume default/missing",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "clone"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					StorageClassName: tc.storageClassName,
+				},
+			}
+			if tc.volumeMode != "" {
+				pvc.Spec.VolumeMode = ptr.To(tc.volumeMode)
+			}
+			if tc.size != "" {
+				pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(tc.size)}
+			}
+
+			err := CheckVolumeCloneSource(context.Background(), c, pvc, tc.source)
+			if tc.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+					t.Fatalf("expected an error containing %q, got %v", tc.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != tc.expectStorageClass {
+				t.Errorf("expected the storage class %s, got %v", tc.expectStorageClass, pvc.Spec.StorageClassName)
+			}
+			if pvc.Spec.VolumeMode == nil || *pvc.Spec.VolumeMode != tc.expectVolumeMode {
+				t.Errorf("expected the volume mode %s, got %v", tc.expectVolumeMode, pvc.Spec.VolumeMode)
+			}
+			if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != tc.expectSize {
+				t.Errorf("expected the size %s, got %s", tc.expectSize, size.String())
+			}
+			if pvc.Spec.DataSource == nil || pvc.Spec.DataSource.Kind != "PersistentVolumeClaim" || pvc.Spec.DataSource.Name != tc.source {
+				t.Errorf("expected the data source %s, got %v", tc.source, pvc.Spec.DataSource)
+			}
+		})
+	}
+}
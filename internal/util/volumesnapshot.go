@@ -0,0 +1,121 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/client"
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+// VolumeSnapshotGVR is the CSI VolumeSnapshot, which is used through the
+// dynamic client since there is no typed client for it.
+var VolumeSnapshotGVR = schema.GroupVersionResource{
+	Group:    constants.VolumeSnapshotAPIGroup,
+	Version:  "v1",
+	Resource: "volumesnapshots",
+}
+
+// csiDriverConfig is an entry of the Harvester setting csi-driver-config.
+type csiDriverConfig struct {
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName"`
+}
+
+// VolumeSnapshotClassName returns the VolumeSnapshotClass Harvester uses for
+// the volumes of the storage class, from the setting csi-driver-config. It
+// returns an empty name, i.e. the default class, if none is configured.
+func VolumeSnapshotClassName(ctx context.Context, c *client.Client, storageClassName string) (string, error) {
+	if storageClassName == "" {
+		return "", nil
+	}
+	storageClass, err := c.StorageClassClient.StorageClasses().Get(ctx, storageClassName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	setting, err := c.HarvesterClient.HarvesterhciV1beta1().Settings().Get(ctx, constants.SettingCSIDriverConfig, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	value := setting.Value
+	if value == "" {
+		value = setting.Default
+	}
+	configs := map[string]csiDriverConfig{}
+	if value != "" {
+		if err = json.Unmarshal([]byte(value), &configs); err != nil {
+			return "", fmt.Errorf("failed to parse the setting %s: %w", constants.SettingCSIDriverConfig, err)
+		}
+	}
+	return configs[storageClass.Provisioner].VolumeSnapshotClassName, nil
+}
+
+// NewVolumeSnapshot returns a VolumeSnapshot of the PVC, an empty className
+// uses the default VolumeSnapshotClass.
+func NewVolumeSnapshot(namespace, name, pvcName, className string) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvcName,
+		},
+	}
+	if className != "" {
+		spec["volumeSnapshotClassName"] = className
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": VolumeSnapshotGVR.GroupVersion().String(),
+			"kind":       constants.VolumeSnapshotKind,
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+			},
+			"spec": spec,
+		},
+	}
+}
+
+// NewVolumeSnapshotDataSource returns the data source of a PVC which is
+// restored from the VolumeSnapshot.
+func NewVolumeSnapshotDataSource(name string) *corev1.TypedLocalObjectReference {
+	return &corev1.TypedLocalObjectReference{
+		APIGroup: ptr.To(constants.VolumeSnapshotAPIGroup),
+		Kind:     constants.VolumeSnapshotKind,
+		Name:     name,
+	}
+}
+
+// WaitForVolumeSnapshotReady polls the VolumeSnapshot until it is ready to
+// use, or returns its error.
+func WaitForVolumeSnapshotReady(ctx context.Context, c *client.Client, namespace, name string) error {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		obj, err := c.DynamicClient.Resource(VolumeSnapshotGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get volume snapshot %s/%s: %w", namespace, name, err)
+		}
+		if ready, _, _ := unstructured.NestedBool(obj.Object, "status", "readyToUse"); ready {
+			return nil
+		}
+		if message, _, _ := unstructured.NestedString(obj.Object, "status", "error", "message"); message != "" {
+			return fmt.Errorf("volume snapshot %s/%s failed: %s", namespace, name, message)
+		}
+		tflog.Debug(ctx, "waiting for the volume snapshot to be ready", map[string]interface{}{
+			"volume_snapshot": namespace + "/" + name,
+		})
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("volume snapshot %s/%s is not ready after waiting: %w", namespace, name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,6 +2,8 @@ package client
 
 import (
 	"encoding/base64"
+	"fmt"
+	"strings"
 
 	"github.com/mitchellh/go-homedir"
 
@@ -12,6 +14,7 @@ import (
 	harvdeviceclient "github.com/harvester/pcidevices/pkg/generated/clientset/versioned"
 	"github.com/rancher/wrangler/v3/pkg/kubeconfig"
 	kubeschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	storageclient "k8s.io/client-go/kubernetes/typed/storage/v1"
 	"k8s.io/client-go/rest"
@@ -27,6 +30,7 @@ type Client struct {
 	HarvesterNetworkClient      *harvnetworkclient.Clientset
 	HarvesterLoadbalancerClient *harvloadbalancerclient.Clientset
 	HarvesterDeviceClient       *harvdeviceclient.Clientset
+	DynamicClient               dynamic.Interface
 }
 
 func NewClient(kubeConfig, kubeContext string) (*Client, error) {
@@ -40,7 +44,16 @@ func NewClient(kubeConfig, kubeContext string) (*Client, error) {
 			return nil, err
 		}
 	}
+	return NewClientForConfig(restConfig)
+}
+
+// NewClientFromRancher builds a client that talks to a Harvester cluster
+// imported into Rancher through Rancher's /k8s/clusters/<id> proxy.
+func NewClientFromRancher(rancherURL, rancherToken, clusterID, caCert string, insecure bool) (*Client, error) {
+	return NewClientForConfig(restConfigFromRancher(rancherURL, rancherToken, clusterID, caCert, insecure))
+}
 
+func NewClientForConfig(restConfig *rest.Config) (*Client, error) {
 	copyConfig := rest.CopyConfig(restConfig)
 	copyConfig.GroupVersion = &kubeschema.GroupVersion{Group: "subresources.kubevirt.io", Version: "v1"}
 	copyConfig.APIPath = "/apis"
@@ -73,6 +86,10 @@ func NewClient(kubeConfig, kubeContext string) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
 	return &Client{
 		RestConfig:                  restConfig,
 		KubeVirtSubresourceClient:   restClient,
@@ -82,9 +99,24 @@ func NewClient(kubeConfig, kubeContext string) (*Client, error) {
 		HarvesterNetworkClient:      harvNetworkClient,
 		HarvesterLoadbalancerClient: harvLoadbalancerClient,
 		HarvesterDeviceClient:       harvDeviceClient,
+		DynamicClient:               dynamicClient,
 	}, nil
 }
 
+func restConfigFromRancher(rancherURL, rancherToken, clusterID, caCert string, insecure bool) *rest.Config {
+	restConfig := &rest.Config{
+		Host:        fmt.Sprintf("%s/k8s/clusters/%s", strings.TrimSuffix(rancherURL, "/"), clusterID),
+		BearerToken: rancherToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: insecure,
+		},
+	}
+	if caCert != "" {
+		restConfig.TLSClientConfig.CAData = []byte(caCert)
+	}
+	return restConfig
+}
+
 func restConfigFromFile(kubeConfig, kubeContext string) (*rest.Config, error) {
 	clientConfigPath, err := homedir.Expand(kubeConfig)
 	if err != nil {
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRestConfigFromRancher(t *testing.T) {
+	tests := []struct {
+		name       string
+		rancherURL string
+		caCert     string
+		insecure   bool
+		host       string
+	}{
+		{
+			name:       "rancher url",
+			rancherURL: "https://rancher.example.com",
+			host:       "https://rancher.example.com/k8s/clusters/c-m-1",
+		},
+		{
+			name:       "trailing slash",
+			rancherURL: "https://rancher.example.com/",
+			host:       "https://rancher.example.com/k8s/clusters/c-m-1",
+		},
+		{
+			name:       "ca certificate",
+			rancherURL: "https://rancher.example.com",
+			caCert:     "-----BEGIN CERTIFICATE-----",
+			host:       "https://rancher.example.com/k8s/clusters/c-m-1",
+		},
+		{
+			name:       "insecure",
+			rancherURL: "https://rancher.example.com",
+			insecure:   true,
+			host:       "https://rancher.example.com/k8s/clusters/c-m-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restConfig := restConfigFromRancher(tt.rancherURL, "token-abc:secret", "c-m-1", tt.caCert, tt.insecure)
+			if restConfig.Host != tt.host {
+				t.Errorf("expected the host %s, got %s", tt.host, restConfig.Host)
+			}
+			if restConfig.BearerToken != "token-abc:secret" {
+				t.Errorf("expected the bearer token token-abc:secret, got %s", restConfig.BearerToken)
+			}
+			if string(restConfig.TLSClientConfig.CAData) != tt.caCert {
+				t.Errorf("expected the CA %q, got %q", tt.caCert, restConfig.TLSClientConfig.CAData)
+			}
+			if restConfig.TLSClientConfig.Insecure != tt.insecure {
+				t.Errorf("expected insecure=%v, got %v", tt.insecure, restConfig.TLSClientConfig.Insecure)
+			}
+		})
+	}
+}
+
+// TestNewClientFromRancher requests a setting through the Rancher proxy of a
+// TLS server, which is only trusted with its CA or with insecure.
+func TestNewClientFromRancher(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-abc:secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/k8s/clusters/c-m-1/apis/harvesterhci.io/v1beta1/settings/server-version" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"apiVersion": "harvesterhci.io/v1beta1",
+			"kind":       "Setting",
+			"metadata":   map[string]string{"name": "server-version"},
+			"value":      "v1.4.1",
+		})
+	}))
+	defer server.Close()
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	tests := []struct {
+		name          string
+		rancherURL    string
+		token         string
+		caCert        string
+		insecure      bool
+		wantClientErr bool
+		wantErr       bool
+	}{
+		{
+			name:       "ca certificate",
+			rancherURL: server.URL,
+			token:      "token-abc:secret",
+			caCert:     serverCA,
+		},
+		{
+			name:       "insecure",
+			rancherURL: server.URL + "/",
+			token:      "token-abc:secret",
+			insecure:   true,
+		},
+		{
+			name:       "unknown certificate",
+			rancherURL: server.URL,
+			token:      "token-abc:secret",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid token",
+			rancherURL: server.URL,
+			token:      "token-abc:invalid",
+			caCert:     serverCA,
+			wantErr:    true,
+		},
+		{
+			name:          "ca certificate and insecure",
+			rancherURL:    server.URL,
+			token:         "token-abc:secret",
+			caCert:        serverCA,
+			insecure:      true,
+			wantClientErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClientFromRancher(tt.rancherURL, tt.token, "c-m-1", tt.caCert, tt.insecure)
+			if (err != nil) != tt.wantClientErr {
+				t.Fatalf("expected the client error=%v, got %v", tt.wantClientErr, err)
+			}
+			if err != nil {
+				return
+			}
+			setting, err := c.HarvesterClient.HarvesterhciV1beta1().Settings().Get(context.Background(), "server-version", metav1.GetOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if err == nil && setting.Value != "v1.4.1" {
+				t.Errorf("expected the server version v1.4.1, got %s", setting.Value)
+			}
+		})
+	}
+}
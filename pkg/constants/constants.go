@@ -8,6 +8,19 @@ const (
 	FieldProviderKubeConfig  = "kubeconfig"
 	FieldProviderKubeContext = "kubecontext"
 
+	FieldProviderRancherURL           = "rancher_url"
+	FieldProviderRancherToken         = "rancher_token"
+	FieldProviderClusterID            = "cluster_id"
+	FieldProviderRancherCACertificate = "rancher_ca_certificate"
+	FieldProviderRancherInsecure      = "rancher_insecure"
+
+	FieldProviderDefaultTags      = "default_tags"
+	FieldProviderDefaultLabels    = "default_labels"
+	FieldProviderDefaultNamespace = "default_namespace"
+
+	FieldProviderReadOnly          = "read_only"
+	FieldProviderAllowedNamespaces = "allowed_namespaces"
+
 	FieldCommonName        = "name"
 	FieldCommonNamespace   = "namespace"
 	FieldCommonTags        = "tags"
@@ -15,6 +28,16 @@ const (
 	FieldCommonDescription = "description"
 	FieldCommonState       = "state"
 	FieldCommonMessage     = "message"
+	FieldCommonTagsAll     = "tags_all"
+	FieldCommonLabelsAll   = "labels_all"
+
+	FieldCommonDeletionProtection = "deletion_protection"
+
+	// AnnotationPrefixTerraform prefixes the annotations which the provider
+	// keeps its own bookkeeping in.
+	AnnotationPrefixTerraform = "terraform.harvesterhci.io/"
+
+	AnnotationDeletionProtection = AnnotationPrefixTerraform + "deletion-protection"
 
 	StateCommonActive  = "Active"
 	StateCommonReady   = "Ready"
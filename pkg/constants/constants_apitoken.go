@@ -0,0 +1,18 @@
+package constants
+
+const (
+	ResourceTypeAPIToken = "harvester_api_token"
+
+	FieldAPITokenAPIURL        = "api_url"
+	FieldAPITokenUsername      = "username"
+	FieldAPITokenPassword      = "password"
+	FieldAPITokenDescription   = "description"
+	FieldAPITokenTTL           = "ttl"
+	FieldAPITokenClusterID     = "cluster_id"
+	FieldAPITokenCACertificate = "ca_certificate"
+	FieldAPITokenInsecure      = "insecure"
+	FieldAPITokenTLSServerName = "tls_server_name"
+	FieldAPITokenName          = "name"
+	FieldAPITokenToken         = "token"
+	FieldAPITokenExpiresAt     = "expires_at"
+)
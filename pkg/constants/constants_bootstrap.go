@@ -3,9 +3,20 @@ package constants
 const (
 	ResourceTypeBootstrap = "harvester_bootstrap"
 
-	FieldBootstrapAPIURL          = "api_url"
-	FieldBootstrapInitialPassword = "initial_password"
-	FieldBootstrapPassword        = "password"
-	FieldBootstrapKubeConfig      = "kubeconfig"
-	FieldShouldUpdatePassword     = "should_update_password"
+	FieldBootstrapAPIURL                = "api_url"
+	FieldBootstrapInitialPassword       = "initial_password"
+	FieldBootstrapPassword              = "password"
+	FieldBootstrapKubeConfig            = "kubeconfig"
+	FieldShouldUpdatePassword           = "should_update_password"
+	FieldBootstrapCACertificate         = "ca_certificate"
+	FieldBootstrapInsecure              = "insecure"
+	FieldBootstrapTLSServerName         = "tls_server_name"
+	FieldBootstrapKubeConfigContent     = "kube_config"
+	FieldBootstrapKubeConfigExpiresAt   = "kube_config_expires_at"
+	FieldBootstrapWriteKubeConfigFile   = "write_kubeconfig_file"
+	FieldBootstrapReadinessPollInterval = "readiness_poll_interval"
+	FieldBootstrapRevokeSessionToken    = "revoke_session_token"
+	FieldBootstrapSessionTTL            = "session_ttl"
+	FieldBootstrapSessionDescription    = "session_description"
+	FieldBootstrapInitialSettings       = "initial_settings"
 )
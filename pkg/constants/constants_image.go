@@ -1,7 +1,8 @@
 package constants
 
 const (
-	ResourceTypeImage = "harvester_image"
+	ResourceTypeImage  = "harvester_image"
+	ResourceTypeImages = "harvester_images"
 
 	FieldImageBackend                = "backend"
 	FieldImageDisplayName            = "display_name"
@@ -20,6 +21,55 @@ const (
 	FieldImageSourceImageNamespace   = "source_image_namespace"
 	FieldImageChecksum               = "checksum"
 	FieldImageFilePath               = "file_path"
+	FieldImageUploadTimeout          = "upload_timeout"
+	FieldImageUploadRetries          = "upload_retries"
+	FieldImageDownloadViaProvider    = "download_via_provider"
+	FieldImageDownloadHeaders        = "download_headers"
+	FieldImageDownloadCACertificate  = "download_ca_certificate"
+	FieldImageDownloadCredentials    = "download_credentials"
+	FieldImageForceDelete            = "force_delete"
+	FieldImageExportSnapshot         = "export_snapshot"
+	FieldImageEncryption             = "encryption"
+
+	FieldImageEncryptionOperation        = "operation"
+	FieldImageEncryptionSourceImage      = "source_image"
+	FieldImageEncryptionPassphrase       = "passphrase"
+	FieldImageEncryptionSecretName       = "secret_name"
+	FieldImageEncryptionSecretNamespace  = "secret_namespace"
+	FieldImageEncryptionCipher           = "cipher"
+	FieldImageEncryptionHash             = "hash"
+	FieldImageEncryptionKeySize          = "key_size"
+	FieldImageEncryptionPBKDF            = "pbkdf"
+	FieldImageEncryptionNumberOfReplicas = "number_of_replicas"
+	FieldImageUsedBy                     = "used_by"
+
+	FieldImagesDisplayNameRegex = "display_name_regex"
+	FieldImagesOSType           = "os_type"
+	FieldImagesMostRecent       = "most_recent"
+	FieldImagesIDs              = "ids"
+	FieldImagesImages           = "images"
+	FieldImagesCreationTime     = "creation_timestamp"
+
+	FieldImageCredentialsSecretName        = "secret_name"
+	FieldImageCredentialsSecretNamespace   = "secret_namespace"
+	FieldImageCredentialsS3AccessKeyID     = "s3_access_key_id"
+	FieldImageCredentialsS3SecretAccessKey = "s3_secret_access_key"
+	FieldImageCredentialsS3SessionToken    = "s3_session_token"
+	FieldImageCredentialsS3Region          = "s3_region"
+	FieldImageCredentialsS3PresignExpiry   = "s3_presign_expiry"
+
+	// AnnotationImageDownloadURL keeps the url of an image which is downloaded
+	// by the provider and uploaded to Harvester, or which is presigned.
+	AnnotationImageDownloadURL = AnnotationPrefixTerraform + "download-url"
+	// AnnotationImageExportPVCName keeps the pvc_name of an image which is
+	// exported from a snapshot of the volume.
+	AnnotationImageExportPVCName = AnnotationPrefixTerraform + "export-pvc-name"
+	// AnnotationImageEncryption marks an image which is encrypted or decrypted
+	// with the encryption block, with the secret and storage class which are
+	// created for it.
+	AnnotationImageEncryption = AnnotationPrefixTerraform + "encryption"
+	// LabelImageOSType is the OS type of an image, as set by the Harvester UI.
+	LabelImageOSType = "harvesterhci.io/os-type"
 
 	StateImageUploading    = "Uploading"
 	StateImageDownloading  = "Downloading"
@@ -0,0 +1,37 @@
+package constants
+
+const (
+	ResourceTypeLonghornRecurringJob = "harvester_longhorn_recurring_job"
+
+	FieldLonghornRecurringJobTask           = "task"
+	FieldLonghornRecurringJobCron           = "cron"
+	FieldLonghornRecurringJobRetain         = "retain"
+	FieldLonghornRecurringJobConcurrency    = "concurrency"
+	FieldLonghornRecurringJobGroups         = "groups"
+	FieldLonghornRecurringJobSnapshotLabels = "snapshot_labels"
+	FieldLonghornRecurringJobExecutionCount = "execution_count"
+
+	LonghornNamespace        = "longhorn-system"
+	LonghornAPIGroup         = "longhorn.io"
+	LonghornRecurringJobKind = "RecurringJob"
+
+	LonghornRecurringJobTaskSnapshot            = "snapshot"
+	LonghornRecurringJobTaskSnapshotForceCreate = "snapshot-force-create"
+	LonghornRecurringJobTaskSnapshotCleanup     = "snapshot-cleanup"
+	LonghornRecurringJobTaskSnapshotDelete      = "snapshot-delete"
+	LonghornRecurringJobTaskBackup              = "backup"
+	LonghornRecurringJobTaskBackupForceCreate   = "backup-force-create"
+	LonghornRecurringJobTaskFilesystemTrim      = "filesystem-trim"
+
+	// LonghornRecurringJobGroupDefault is the group of the volumes which
+	// have no recurring jobs or groups.
+	LonghornRecurringJobGroupDefault = "default"
+
+	// LabelLonghornRecurringJobSource makes Longhorn apply the recurring job
+	// labels of a PVC to its volume.
+	LabelLonghornRecurringJobSource = "recurring-job.longhorn.io/source"
+	// LabelPrefixLonghornRecurringJobGroup assigns a PVC to the recurring job
+	// group in the rest of the label key.
+	LabelPrefixLonghornRecurringJobGroup  = "recurring-job-group.longhorn.io/"
+	LabelValueLonghornRecurringJobEnabled = "enabled"
+)
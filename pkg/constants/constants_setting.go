@@ -6,4 +6,7 @@ const (
 	FieldSettingValue = "value"
 
 	StateSettingConfigured = "Configured"
+
+	SettingServerVersion   = "server-version"
+	SettingCSIDriverConfig = "csi-driver-config"
 )
@@ -11,6 +11,56 @@ const (
 	FieldStorageClassParameters            = "parameters"
 	FieldStorageClassAllowedTopologies     = "allowed_topologies"
 	FieldStorageClassMatchLabelExpressions = "match_label_expressions"
+	FieldStorageClassLonghorn              = "longhorn"
 
 	//FieldStorageClassMountOptions         = "mount_options"
+
+	FieldLonghornNumberOfReplicas                 = "number_of_replicas"
+	FieldLonghornStaleReplicaTimeout              = "stale_replica_timeout"
+	FieldLonghornDiskSelector                     = "disk_selector"
+	FieldLonghornNodeSelector                     = "node_selector"
+	FieldLonghornDataLocality                     = "data_locality"
+	FieldLonghornEncrypted                        = "encrypted"
+	FieldLonghornEncryptionSecretName             = "encryption_secret_name"
+	FieldLonghornEncryptionSecretNamespace        = "encryption_secret_namespace"
+	FieldLonghornMigratable                       = "migratable"
+	FieldLonghornRecurringJobSelector             = "recurring_job_selector"
+	FieldLonghornRecurringJobSelectorName         = "name"
+	FieldLonghornRecurringJobSelectorIsGroup      = "is_group"
+	FieldLonghornBackingImage                     = "backing_image"
+	FieldLonghornBackingImageName                 = "name"
+	FieldLonghornBackingImageDataSourceType       = "data_source_type"
+	FieldLonghornBackingImageDataSourceParameters = "data_source_parameters"
+	FieldLonghornBackingImageChecksum             = "checksum"
+
+	LonghornParameterNumberOfReplicas                 = "numberOfReplicas"
+	LonghornParameterStaleReplicaTimeout              = "staleReplicaTimeout"
+	LonghornParameterDiskSelector                     = "diskSelector"
+	LonghornParameterNodeSelector                     = "nodeSelector"
+	LonghornParameterDataLocality                     = "dataLocality"
+	LonghornParameterEncrypted                        = "encrypted"
+	LonghornParameterMigratable                       = "migratable"
+	LonghornParameterRecurringJobSelector             = "recurringJobSelector"
+	LonghornParameterBackingImage                     = "backingImage"
+	LonghornParameterBackingImageDataSourceType       = "backingImageDataSourceType"
+	LonghornParameterBackingImageDataSourceParameters = "backingImageDataSourceParameters"
+	LonghornParameterBackingImageChecksum             = "backingImageChecksum"
+	LonghornParameterProvisionerSecretName            = "csi.storage.k8s.io/provisioner-secret-name"
+	LonghornParameterProvisionerSecretNamespace       = "csi.storage.k8s.io/provisioner-secret-namespace"
+	LonghornParameterNodePublishSecretName            = "csi.storage.k8s.io/node-publish-secret-name"
+	LonghornParameterNodePublishSecretNamespace       = "csi.storage.k8s.io/node-publish-secret-namespace"
+	LonghornParameterNodeStageSecretName              = "csi.storage.k8s.io/node-stage-secret-name"
+	LonghornParameterNodeStageSecretNamespace         = "csi.storage.k8s.io/node-stage-secret-namespace"
+
+	LonghornDataLocalityDisabled    = "disabled"
+	LonghornDataLocalityBestEffort  = "best-effort"
+	LonghornDataLocalityStrictLocal = "strict-local"
+
+	LonghornBackingImageDataSourceTypeDownload         = "download"
+	LonghornBackingImageDataSourceTypeUpload           = "upload"
+	LonghornBackingImageDataSourceTypeExportFromVolume = "export-from-volume"
+
+	// AnnotationStorageClassLonghornParameters lists the parameters of a
+	// storage class which are set by the longhorn block, separated by commas.
+	AnnotationStorageClassLonghornParameters = AnnotationPrefixTerraform + "longhorn-parameters"
 )
@@ -37,8 +37,10 @@ const (
 )
 
 const (
-	ResourceVirtualMachine = "virtualmachines"
-	SubresourceRestart     = "restart"
+	ResourceVirtualMachine  = "virtualmachines"
+	SubresourceRestart      = "restart"
+	SubresourceAddVolume    = "addvolume"
+	SubresourceRemoveVolume = "removevolume"
 )
 
 const (
@@ -75,6 +77,8 @@ const (
 	FieldDiskHotPlug            = "hot_plug"
 	FieldDiskAutoDelete         = "auto_delete"
 	FieldDiskVolumeName         = "volume_name"
+	FieldDiskSourceSnapshot     = "source_snapshot"
+	FieldDiskSourceVolume       = "source_volume"
 
 	AnnotationDiskAutoDelete = "terraform-provider-harvester-auto-delete"
 )
@@ -1,16 +1,35 @@
 package constants
 
 const (
-	ResourceTypeVolume = "harvester_volume"
+	ResourceTypeVolume  = "harvester_volume"
+	ResourceTypeVolumes = "harvester_volumes"
 
-	FieldVolumeSize             = "size"
-	FieldVolumeImage            = "image"
-	FieldVolumeStorageClassName = "storage_class_name"
-	FieldVolumeMode             = "volume_mode"
-	FieldVolumeAccessMode       = "access_mode"
-	FieldVolumeAttachedVM       = "attached_vm"
+	FieldVolumeSize               = "size"
+	FieldVolumeImage              = "image"
+	FieldVolumeStorageClassName   = "storage_class_name"
+	FieldVolumeMode               = "volume_mode"
+	FieldVolumeAccessMode         = "access_mode"
+	FieldVolumeAttachedVM         = "attached_vm"
+	FieldVolumeSourceSnapshot     = "source_snapshot"
+	FieldVolumeSourceVolume       = "source_volume"
+	FieldVolumeLastAttachedVM     = "last_attached_vm"
+	FieldVolumeRecurringJobGroups = "recurring_job_groups"
+
+	FieldVolumesUnattached   = "unattached"
+	FieldVolumesOlderThan    = "older_than"
+	FieldVolumesIDs          = "ids"
+	FieldVolumesVolumes      = "volumes"
+	FieldVolumesCreationTime = "creation_timestamp"
 
 	FieldPhase = "phase"
 
 	StateVolumeInUse = "In-use"
+
+	// AnnotationVolumeLastAttachedVM keeps the virtual machine which a volume
+	// was attached to, when the volume outlives the virtual machine.
+	AnnotationVolumeLastAttachedVM = AnnotationPrefixTerraform + "last-attached-vm"
+
+	// VolumeOwnerSchemaVirtualMachine is the schema of virtual machines in the
+	// Harvester owner annotation of volumes.
+	VolumeOwnerSchemaVirtualMachine = "kubevirt.io.virtualmachine"
 )
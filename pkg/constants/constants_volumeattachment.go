@@ -0,0 +1,19 @@
+package constants
+
+const (
+	ResourceTypeVolumeAttachment = "harvester_virtualmachine_volume_attachment"
+
+	FieldVolumeAttachmentVirtualMachineName = "virtual_machine_name"
+	FieldVolumeAttachmentVolumeName         = "volume_name"
+	FieldVolumeAttachmentDiskName           = "disk_name"
+	FieldVolumeAttachmentBus                = "bus"
+	FieldVolumeAttachmentHotPlug            = "hot_plug"
+
+	// AnnotationVolumeAttachments lists the disks of a virtual machine which
+	// are managed by volume attachments, separated by commas.
+	AnnotationVolumeAttachments = AnnotationPrefixTerraform + "volume-attachments"
+
+	StateVolumeAttachmentAttaching = "Attaching"
+	StateVolumeAttachmentAttached  = "Attached"
+	StateVolumeAttachmentDetaching = "Detaching"
+)
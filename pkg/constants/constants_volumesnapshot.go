@@ -0,0 +1,18 @@
+package constants
+
+const (
+	ResourceTypeVolumeSnapshot = "harvester_volume_snapshot"
+
+	FieldVolumeSnapshotVolumeName              = "volume_name"
+	FieldVolumeSnapshotVolumeSnapshotClassName = "volume_snapshot_class_name"
+	FieldVolumeSnapshotReadyToUse              = "ready_to_use"
+	FieldVolumeSnapshotRestoreSize             = "restore_size"
+	FieldVolumeSnapshotCreationTime            = "creation_time"
+)
+
+const (
+	VolumeSnapshotAPIGroup = "snapshot.storage.k8s.io"
+	VolumeSnapshotKind     = "VolumeSnapshot"
+
+	StateVolumeSnapshotInProgress = "In Progress"
+)
@@ -1,9 +1,14 @@
 package importer
 
 import (
+	"encoding/json"
 	"strings"
 
 	"github.com/harvester/harvester/pkg/builder"
+	"github.com/harvester/harvester/pkg/ref"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
 )
 
 type StateGetter struct {
@@ -43,3 +48,57 @@ func GetLabels(labels map[string]string) map[string]string {
 func GetDescriptions(annotations map[string]string) string {
 	return annotations[builder.AnnotationKeyDescription]
 }
+
+func GetDeletionProtection(annotations map[string]string) bool {
+	return annotations[constants.AnnotationDeletionProtection] == "true"
+}
+
+// GetSourceSnapshot returns the name of the VolumeSnapshot a PVC is restored
+// from, or an empty name.
+func GetSourceSnapshot(dataSource *corev1.TypedLocalObjectReference) string {
+	if dataSource == nil || dataSource.Kind != constants.VolumeSnapshotKind ||
+		dataSource.APIGroup == nil || *dataSource.APIGroup != constants.VolumeSnapshotAPIGroup {
+		return ""
+	}
+	return dataSource.Name
+}
+
+// GetSourceVolume returns the name of the PVC a PVC is cloned from, or an
+// empty name.
+func GetSourceVolume(dataSource *corev1.TypedLocalObjectReference) string {
+	if dataSource == nil || dataSource.Kind != "PersistentVolumeClaim" ||
+		(dataSource.APIGroup != nil && *dataSource.APIGroup != "") {
+		return ""
+	}
+	return dataSource.Name
+}
+
+// GetVolumeAttachments returns the disks of the virtual machine which are
+// managed by volume attachments.
+func GetVolumeAttachments(annotations map[string]string) map[string]bool {
+	attachments := map[string]bool{}
+	for _, diskName := range strings.Split(annotations[constants.AnnotationVolumeAttachments], ",") {
+		if diskName != "" {
+			attachments[diskName] = true
+		}
+	}
+	return attachments
+}
+
+// GetLastAttachedVM returns the virtual machine a volume was last attached to,
+// which is the latest virtual machine owner recorded by Harvester, or the one
+// recorded by the provider when the volume outlives the virtual machine.
+func GetLastAttachedVM(annotations map[string]string) string {
+	var owners []struct {
+		Schema string   `json:"schema"`
+		Refs   []string `json:"refs"`
+	}
+	if err := json.Unmarshal([]byte(annotations[ref.AnnotationSchemaOwnerKeyName]), &owners); err == nil {
+		for _, owner := range owners {
+			if owner.Schema == constants.VolumeOwnerSchemaVirtualMachine && len(owner.Refs) > 0 {
+				return owner.Refs[len(owner.Refs)-1]
+			}
+		}
+	}
+	return annotations[constants.AnnotationVolumeLastAttachedVM]
+}
@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/harvester/harvester/pkg/ref"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func TestGetLastAttachedVM(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expectation string
+	}{
+		{
+			name:        "never attached",
+			annotations: map[string]string{},
+			expectation: "",
+		},
+		{
+			name: "latest owner",
+			annotations: map[string]string{
+				ref.AnnotationSchemaOwnerKeyName:         `[{"schema":"` + constants.VolumeOwnerSchemaVirtualMachine + `","refs":["default/vm1","default/vm2"]}]`,
+				constants.AnnotationVolumeLastAttachedVM: "default/vm0",
+			},
+			expectation: "default/vm2",
+		},
+		{
+			name: "owner of another schema",
+			annotations: map[string]string{
+				ref.AnnotationSchemaOwnerKeyName:         `[{"schema":"other","refs":["default/other"]}]`,
+				constants.AnnotationVolumeLastAttachedVM: "default/vm0",
+			},
+			expectation: "default/vm0",
+		},
+		{
+			name: "recorded by the provider",
+			annotations: map[string]string{
+				ref.AnnotationSchemaOwnerKeyName:         `[{"schema":"` + constants.VolumeOwnerSchemaVirtualMachine + `","refs":[]}]`,
+				constants.AnnotationVolumeLastAttachedVM: "default/vm0",
+			},
+			expectation: "default/vm0",
+		},
+		{
+			name: "invalid owners",
+			annotations: map[string]string{
+				ref.AnnotationSchemaOwnerKeyName:         "{",
+				constants.AnnotationVolumeLastAttachedVM: "default/vm0",
+			},
+			expectation: "default/vm0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := GetLastAttachedVM(tc.annotations); actual != tc.expectation {
+				t.Errorf("expected %q, got %q", tc.expectation, actual)
+			}
+		})
+	}
+}
+
+func TestGetVolumeAttachments(t *testing.T) {
+	attachments := GetVolumeAttachments(map[string]string{constants.AnnotationVolumeAttachments: "data,,backup"})
+	if len(attachments) != 2 || !attachments["data"] || !attachments["backup"] {
+		t.Errorf("expected the disks data and backup, got %v", attachments)
+	}
+	if attachments = GetVolumeAttachments(nil); len(attachments) != 0 {
+		t.Errorf("expected no disks, got %v", attachments)
+	}
+}
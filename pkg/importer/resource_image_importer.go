@@ -14,6 +14,7 @@ func ResourceImageStateGetter(obj *harvsterv1.VirtualMachineImage) (*StateGetter
 		constants.FieldCommonNamespace:             obj.Namespace,
 		constants.FieldCommonName:                  obj.Name,
 		constants.FieldCommonDescription:           GetDescriptions(obj.Annotations),
+		constants.FieldCommonDeletionProtection:    GetDeletionProtection(obj.Annotations),
 		constants.FieldCommonTags:                  GetTags(obj.Labels),
 		constants.FieldCommonLabels:                GetLabels(obj.Labels),
 		constants.FieldImageDisplayName:            obj.Spec.DisplayName,
@@ -27,6 +28,22 @@ func ResourceImageStateGetter(obj *harvsterv1.VirtualMachineImage) (*StateGetter
 		constants.FieldImageVolumeStorageClassName: obj.Status.StorageClassName,
 		constants.FieldImageStorageClassParameters: obj.Spec.StorageClassParameters,
 		constants.FieldImageStorageClassName:       obj.Annotations[harvsterutil.AnnotationStorageClassName],
+		constants.FieldImageDownloadViaProvider:    false,
+		constants.FieldImageExportSnapshot:         false,
+	}
+
+	// The image is downloaded by the provider and uploaded to Harvester, or
+	// Harvester downloads a presigned url.
+	if imageURL, ok := obj.Annotations[constants.AnnotationImageDownloadURL]; ok {
+		states[constants.FieldImageSourceType] = harvsterv1.VirtualMachineImageSourceTypeDownload
+		states[constants.FieldImageURL] = imageURL
+		states[constants.FieldImageDownloadViaProvider] = obj.Spec.SourceType == harvsterv1.VirtualMachineImageSourceTypeUpload
+	}
+
+	// The image is exported from a snapshot of the volume.
+	if pvcName, ok := obj.Annotations[constants.AnnotationImageExportPVCName]; ok {
+		states[constants.FieldImagePVCName] = pvcName
+		states[constants.FieldImageExportSnapshot] = true
 	}
 
 	// Handle security parameters
@@ -40,12 +57,18 @@ func ResourceImageStateGetter(obj *harvsterv1.VirtualMachineImage) (*StateGetter
 	} else {
 		states[constants.FieldImageSecurityParameters] = map[string]interface{}{}
 	}
+	// The security parameters are set by the encryption block.
+	if _, ok := obj.Annotations[constants.AnnotationImageEncryption]; ok {
+		states[constants.FieldImageSecurityParameters] = map[string]interface{}{}
+	}
 
 	var (
-		state       string
-		InitMessage string
-		initialized bool
-		imported    bool
+		state         string
+		InitMessage   string
+		initialized   bool
+		imported      bool
+		importFailed  bool
+		importMessage string
 	)
 	for _, condition := range obj.Status.Conditions {
 		switch condition.Type {
@@ -54,10 +77,16 @@ func ResourceImageStateGetter(obj *harvsterv1.VirtualMachineImage) (*StateGetter
 			InitMessage = condition.Message
 		case harvsterv1.ImageImported:
 			imported = condition.Status == corev1.ConditionTrue
+			// the download, upload or export failed and is not retried anymore
+			importFailed = condition.Status == corev1.ConditionFalse
+			importMessage = condition.Message
 		}
 	}
 	if initialized {
-		if imported {
+		if importFailed {
+			state = constants.StateCommonFailed
+			InitMessage = importMessage
+		} else if imported {
 			state = constants.StateCommonActive
 		} else {
 			switch obj.Spec.SourceType {
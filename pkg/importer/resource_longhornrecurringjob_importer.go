@@ -0,0 +1,40 @@
+package importer
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+)
+
+func ResourceLonghornRecurringJobStateGetter(obj *unstructured.Unstructured) (*StateGetter, error) {
+	task, _, _ := unstructured.NestedString(obj.Object, "spec", "task")
+	cron, _, _ := unstructured.NestedString(obj.Object, "spec", "cron")
+	retain, _, _ := unstructured.NestedInt64(obj.Object, "spec", "retain")
+	concurrency, _, _ := unstructured.NestedInt64(obj.Object, "spec", "concurrency")
+	groups, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "groups")
+	snapshotLabels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "labels")
+	executionCount, _, _ := unstructured.NestedInt64(obj.Object, "status", "executionCount")
+
+	states := map[string]interface{}{
+		constants.FieldCommonName:                         obj.GetName(),
+		constants.FieldCommonDescription:                  GetDescriptions(obj.GetAnnotations()),
+		constants.FieldCommonDeletionProtection:           GetDeletionProtection(obj.GetAnnotations()),
+		constants.FieldCommonTags:                         GetTags(obj.GetLabels()),
+		constants.FieldCommonLabels:                       GetLabels(obj.GetLabels()),
+		constants.FieldLonghornRecurringJobTask:           task,
+		constants.FieldLonghornRecurringJobCron:           cron,
+		constants.FieldLonghornRecurringJobRetain:         int(retain),
+		constants.FieldLonghornRecurringJobConcurrency:    int(concurrency),
+		constants.FieldLonghornRecurringJobGroups:         groups,
+		constants.FieldLonghornRecurringJobSnapshotLabels: snapshotLabels,
+		constants.FieldLonghornRecurringJobExecutionCount: int(executionCount),
+		constants.FieldCommonState:                        constants.StateCommonActive,
+	}
+	return &StateGetter{
+		ID:           helper.BuildID("", obj.GetName()),
+		Name:         obj.GetName(),
+		ResourceType: constants.ResourceTypeLonghornRecurringJob,
+		States:       states,
+	}, nil
+}
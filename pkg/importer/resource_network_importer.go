@@ -44,6 +44,7 @@ func ResourceNetworkStateGetter(obj *nadv1.NetworkAttachmentDefinition) (*StateG
 		constants.FieldCommonNamespace:           obj.Namespace,
 		constants.FieldCommonName:                obj.Name,
 		constants.FieldCommonDescription:         GetDescriptions(obj.Annotations),
+		constants.FieldCommonDeletionProtection:  GetDeletionProtection(obj.Annotations),
 		constants.FieldCommonTags:                GetTags(obj.Labels),
 		constants.FieldCommonLabels:              GetLabels(obj.Labels),
 		constants.FieldNetworkVlanID:             vlanID,
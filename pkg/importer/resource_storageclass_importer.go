@@ -1,6 +1,10 @@
 package importer
 
 import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 
@@ -9,13 +13,19 @@ import (
 )
 
 func ResourceStorageClassStateGetter(obj *storagev1.StorageClass) (*StateGetter, error) {
+	parameters, longhorn, err := getLonghornParameters(obj)
+	if err != nil {
+		return nil, err
+	}
 	states := map[string]interface{}{
 		constants.FieldCommonName:                       obj.Name,
 		constants.FieldCommonDescription:                GetDescriptions(obj.Annotations),
+		constants.FieldCommonDeletionProtection:         GetDeletionProtection(obj.Annotations),
 		constants.FieldCommonTags:                       GetTags(obj.Labels),
 		constants.FieldCommonLabels:                     GetLabels(obj.Labels),
 		constants.FieldStorageClassVolumeProvisioner:    obj.Provisioner,
-		constants.FieldStorageClassParameters:           obj.Parameters,
+		constants.FieldStorageClassParameters:           parameters,
+		constants.FieldStorageClassLonghorn:             longhorn,
 		constants.FieldStorageClassAllowVolumeExpansion: *obj.AllowVolumeExpansion,
 		constants.FieldStorageClassReclaimPolicy:        string(*obj.ReclaimPolicy),
 		constants.FieldStorageClassVolumeBindingMode:    string(*obj.VolumeBindingMode),
@@ -50,3 +60,93 @@ func getAllowedTopologies(topologies []corev1.TopologySelectorTerm) []map[string
 	}
 	return result
 }
+
+// getLonghornParameters splits the parameters of the storage class into the
+// parameters which are set by the longhorn block and the others.
+func getLonghornParameters(obj *storagev1.StorageClass) (map[string]string, []map[string]interface{}, error) {
+	keys := obj.Annotations[constants.AnnotationStorageClassLonghornParameters]
+	if keys == "" {
+		return obj.Parameters, nil, nil
+	}
+	parameters := make(map[string]string, len(obj.Parameters))
+	for key, value := range obj.Parameters {
+		parameters[key] = value
+	}
+	longhornParameters := map[string]string{}
+	for _, key := range strings.Split(keys, ",") {
+		if value, ok := parameters[key]; ok {
+			longhornParameters[key] = value
+			delete(parameters, key)
+		}
+	}
+
+	longhorn := map[string]interface{}{
+		constants.FieldLonghornNumberOfReplicas:          0,
+		constants.FieldLonghornStaleReplicaTimeout:       0,
+		constants.FieldLonghornDiskSelector:              splitTags(longhornParameters[constants.LonghornParameterDiskSelector]),
+		constants.FieldLonghornNodeSelector:              splitTags(longhornParameters[constants.LonghornParameterNodeSelector]),
+		constants.FieldLonghornDataLocality:              longhornParameters[constants.LonghornParameterDataLocality],
+		constants.FieldLonghornEncrypted:                 longhornParameters[constants.LonghornParameterEncrypted] == "true",
+		constants.FieldLonghornEncryptionSecretName:      longhornParameters[constants.LonghornParameterProvisionerSecretName],
+		constants.FieldLonghornEncryptionSecretNamespace: longhornParameters[constants.LonghornParameterProvisionerSecretNamespace],
+		constants.FieldLonghornMigratable:                longhornParameters[constants.LonghornParameterMigratable] == "true",
+		constants.FieldLonghornRecurringJobSelector:      []map[string]interface{}{},
+		constants.FieldLonghornBackingImage:              []map[string]interface{}{},
+	}
+	for field, key := range map[string]string{
+		constants.FieldLonghornNumberOfReplicas:    constants.LonghornParameterNumberOfReplicas,
+		constants.FieldLonghornStaleReplicaTimeout: constants.LonghornParameterStaleReplicaTimeout,
+	} {
+		if value := longhornParameters[key]; value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			longhorn[field] = number
+		}
+	}
+	if value := longhornParameters[constants.LonghornParameterRecurringJobSelector]; value != "" {
+		var selectors []struct {
+			Name    string `json:"name"`
+			IsGroup bool   `json:"isGroup"`
+		}
+		if err := json.Unmarshal([]byte(value), &selectors); err != nil {
+			return nil, nil, err
+		}
+		recurringJobSelector := make([]map[string]interface{}, 0, len(selectors))
+		for _, selector := range selectors {
+			recurringJobSelector = append(recurringJobSelector, map[string]interface{}{
+				constants.FieldLonghornRecurringJobSelectorName:    selector.Name,
+				constants.FieldLonghornRecurringJobSelectorIsGroup: selector.IsGroup,
+			})
+		}
+		longhorn[constants.FieldLonghornRecurringJobSelector] = recurringJobSelector
+	}
+	if name := longhornParameters[constants.LonghornParameterBackingImage]; name != "" {
+		dataSourceParameters := map[string]string{}
+		if value := longhornParameters[constants.LonghornParameterBackingImageDataSourceParameters]; value != "" {
+			if err := json.Unmarshal([]byte(value), &dataSourceParameters); err != nil {
+				return nil, nil, err
+			}
+		}
+		longhorn[constants.FieldLonghornBackingImage] = []map[string]interface{}{
+			{
+				constants.FieldLonghornBackingImageName:                 name,
+				constants.FieldLonghornBackingImageDataSourceType:       longhornParameters[constants.LonghornParameterBackingImageDataSourceType],
+				constants.FieldLonghornBackingImageDataSourceParameters: dataSourceParameters,
+				constants.FieldLonghornBackingImageChecksum:             longhornParameters[constants.LonghornParameterBackingImageChecksum],
+			},
+		}
+	}
+	return parameters, []map[string]interface{}{longhorn}, nil
+}
+
+func splitTags(tags string) []string {
+	result := []string{}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
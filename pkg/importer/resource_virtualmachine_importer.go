@@ -239,6 +239,8 @@ func (v *VMImporter) pvcVolume(volume kubevirtv1.Volume, state map[string]interf
 					state[constants.FieldVolumeStorageClassName] = *pvcTemplate.Spec.StorageClassName
 				}
 				state[constants.FieldDiskAutoDelete] = pvcTemplate.Annotations[constants.AnnotationDiskAutoDelete] == "true"
+				state[constants.FieldDiskSourceSnapshot] = GetSourceSnapshot(pvcTemplate.Spec.DataSource)
+				state[constants.FieldDiskSourceVolume] = GetSourceVolume(pvcTemplate.Spec.DataSource)
 				isInPVCTemplates = true
 				break
 			}
@@ -297,7 +299,12 @@ func (v *VMImporter) Volume() ([]map[string]interface{}, []map[string]interface{
 		volumesMap[volume.Name] = volume
 	}
 
+	// the disks of volume attachments are managed apart from the virtual machine
+	attachments := GetVolumeAttachments(v.VirtualMachine.Annotations)
 	for _, disk := range disks {
+		if attachments[disk.Name] {
+			continue
+		}
 		diskState := make(map[string]interface{})
 		var (
 			diskType string
@@ -409,6 +416,7 @@ func ResourceVirtualMachineStateGetter(vm *kubevirtv1.VirtualMachine, vmi *kubev
 			constants.FieldCommonNamespace:                     vm.Namespace,
 			constants.FieldCommonName:                          vm.Name,
 			constants.FieldCommonDescription:                   GetDescriptions(vm.Annotations),
+			constants.FieldCommonDeletionProtection:            GetDeletionProtection(vm.Annotations),
 			constants.FieldCommonTags:                          GetTags(vm.Labels),
 			constants.FieldCommonLabels:                        GetLabels(vm.Labels),
 			constants.FieldCommonState:                         vmImporter.State(networkInterface, oldInstanceUID),
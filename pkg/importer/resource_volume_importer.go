@@ -15,14 +15,19 @@ import (
 )
 
 func ResourceVolumeStateGetter(client *client.Client, obj *corev1.PersistentVolumeClaim) (*StateGetter, error) {
+	labels, recurringJobGroups := getRecurringJobGroups(GetLabels(obj.Labels))
 	states := map[string]interface{}{
-		constants.FieldCommonNamespace:   obj.Namespace,
-		constants.FieldCommonName:        obj.Name,
-		constants.FieldCommonDescription: GetDescriptions(obj.Annotations),
-		constants.FieldCommonTags:        GetTags(obj.Labels),
-		constants.FieldCommonLabels:      GetLabels(obj.Labels),
-		constants.FieldVolumeSize:        obj.Spec.Resources.Requests.Storage().String(),
-		constants.FieldPhase:             obj.Status.Phase,
+		constants.FieldCommonNamespace:          obj.Namespace,
+		constants.FieldCommonName:               obj.Name,
+		constants.FieldCommonDescription:        GetDescriptions(obj.Annotations),
+		constants.FieldCommonDeletionProtection: GetDeletionProtection(obj.Annotations),
+		constants.FieldCommonTags:               GetTags(obj.Labels),
+		constants.FieldCommonLabels:             labels,
+		constants.FieldVolumeRecurringJobGroups: recurringJobGroups,
+		constants.FieldVolumeSize:               obj.Spec.Resources.Requests.Storage().String(),
+		constants.FieldVolumeSourceSnapshot:     GetSourceSnapshot(obj.Spec.DataSource),
+		constants.FieldVolumeSourceVolume:       GetSourceVolume(obj.Spec.DataSource),
+		constants.FieldPhase:                    obj.Status.Phase,
 	}
 	if obj.Spec.VolumeMode != nil {
 		states[constants.FieldVolumeMode] = obj.Spec.VolumeMode
@@ -51,9 +56,13 @@ func ResourceVolumeStateGetter(client *client.Client, obj *corev1.PersistentVolu
 
 	attachedList := []string{}
 	for _, vm := range vms.Items {
+		if vm.Spec.Template == nil {
+			continue
+		}
 		for _, vol := range vm.Spec.Template.Spec.Volumes {
-			if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName != "" {
-				attachedList = append(attachedList, ref.Construct(obj.Namespace, obj.Name))
+			if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == obj.Name {
+				attachedList = append(attachedList, ref.Construct(vm.Namespace, vm.Name))
+				break
 			}
 		}
 	}
@@ -71,3 +80,23 @@ func ResourceVolumeStateGetter(client *client.Client, obj *corev1.PersistentVolu
 		States:       states,
 	}, nil
 }
+
+// getRecurringJobGroups splits the labels which assign the volume to Longhorn
+// recurring job groups from the others.
+func getRecurringJobGroups(labels map[string]string) (map[string]string, []string) {
+	if labels[constants.LabelLonghornRecurringJobSource] != constants.LabelValueLonghornRecurringJobEnabled {
+		return labels, []string{}
+	}
+	others := map[string]string{}
+	groups := []string{}
+	for key, value := range labels {
+		switch {
+		case key == constants.LabelLonghornRecurringJobSource:
+		case strings.HasPrefix(key, constants.LabelPrefixLonghornRecurringJobGroup) && value == constants.LabelValueLonghornRecurringJobEnabled:
+			groups = append(groups, strings.TrimPrefix(key, constants.LabelPrefixLonghornRecurringJobGroup))
+		default:
+			others[key] = value
+		}
+	}
+	return others, groups
+}
@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func TestGetRecurringJobGroups(t *testing.T) {
+	testCases := []struct {
+		name           string
+		labels         map[string]string
+		expectedLabels map[string]string
+		expectedGroups []string
+	}{
+		{
+			name:           "no labels",
+			labels:         map[string]string{},
+			expectedLabels: map[string]string{},
+			expectedGroups: []string{},
+		},
+		{
+			name: "groups without the source label are labels",
+			labels: map[string]string{
+				constants.LabelPrefixLonghornRecurringJobGroup + "daily": constants.LabelValueLonghornRecurringJobEnabled,
+				"team": "infra",
+			},
+			expectedLabels: map[string]string{
+				constants.LabelPrefixLonghornRecurringJobGroup + "daily": constants.LabelValueLonghornRecurringJobEnabled,
+				"team": "infra",
+			},
+			expectedGroups: []string{},
+		},
+		{
+			name: "groups",
+			labels: map[string]string{
+				constants.LabelLonghornRecurringJobSource:                 constants.LabelValueLonghornRecurringJobEnabled,
+				constants.LabelPrefixLonghornRecurringJobGroup + "daily":  constants.LabelValueLonghornRecurringJobEnabled,
+				constants.LabelPrefixLonghornRecurringJobGroup + "weekly": constants.LabelValueLonghornRecurringJobEnabled,
+				constants.LabelPrefixLonghornRecurringJobGroup + "paused": "disabled",
+				"team": "infra",
+			},
+			expectedLabels: map[string]string{
+				constants.LabelPrefixLonghornRecurringJobGroup + "paused": "disabled",
+				"team": "infra",
+			},
+			expectedGroups: []string{"daily", "weekly"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels, groups := getRecurringJobGroups(tc.labels)
+			sort.Strings(groups)
+			if !reflect.DeepEqual(labels, tc.expectedLabels) {
+				t.Errorf("expected the labels %v, got %v", tc.expectedLabels, labels)
+			}
+			if !reflect.DeepEqual(groups, tc.expectedGroups) {
+				t.Errorf("expected the groups %v, got %v", tc.expectedGroups, groups)
+			}
+		})
+	}
+}
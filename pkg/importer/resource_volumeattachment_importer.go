@@ -0,0 +1,54 @@
+package importer
+
+import (
+	"fmt"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+)
+
+func ResourceVolumeAttachmentStateGetter(vm *kubevirtv1.VirtualMachine, vmi *kubevirtv1.VirtualMachineInstance, diskName string) (*StateGetter, error) {
+	var (
+		disk   *kubevirtv1.Disk
+		volume *kubevirtv1.Volume
+	)
+	for i := range vm.Spec.Template.Spec.Domain.Devices.Disks {
+		if vm.Spec.Template.Spec.Domain.Devices.Disks[i].Name == diskName {
+			disk = &vm.Spec.Template.Spec.Domain.Devices.Disks[i]
+		}
+	}
+	for i := range vm.Spec.Template.Spec.Volumes {
+		if vm.Spec.Template.Spec.Volumes[i].Name == diskName {
+			volume = &vm.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	if disk == nil || disk.Disk == nil || volume == nil || volume.PersistentVolumeClaim == nil {
+		return nil, fmt.Errorf("virtual machine %s/%s has no volume disk %s", vm.Namespace, vm.Name, diskName)
+	}
+
+	states := map[string]interface{}{
+		constants.FieldCommonNamespace:                    vm.Namespace,
+		constants.FieldVolumeAttachmentVirtualMachineName: vm.Name,
+		constants.FieldVolumeAttachmentVolumeName:         volume.PersistentVolumeClaim.ClaimName,
+		constants.FieldVolumeAttachmentDiskName:           diskName,
+		constants.FieldVolumeAttachmentBus:                string(disk.Disk.Bus),
+		constants.FieldVolumeAttachmentHotPlug:            volume.PersistentVolumeClaim.Hotpluggable,
+		constants.FieldCommonState:                        constants.StateVolumeAttachmentAttached,
+	}
+	if vmi != nil && volume.PersistentVolumeClaim.Hotpluggable {
+		states[constants.FieldCommonState] = constants.StateVolumeAttachmentAttaching
+		for _, volumeStatus := range vmi.Status.VolumeStatus {
+			if volumeStatus.Name == diskName && volumeStatus.Phase == kubevirtv1.VolumeReady {
+				states[constants.FieldCommonState] = constants.StateVolumeAttachmentAttached
+			}
+		}
+	}
+	return &StateGetter{
+		ID:           helper.BuildID(vm.Namespace, vm.Name+helper.IDSep+diskName),
+		Name:         diskName,
+		ResourceType: constants.ResourceTypeVolumeAttachment,
+		States:       states,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+)
+
+func TestResourceVolumeAttachmentStateGetter(t *testing.T) {
+	newVM := func(hotPlug bool) *kubevirtv1.VirtualMachine {
+		return &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm"},
+			Spec: kubevirtv1.VirtualMachineSpec{
+				Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+					Spec: kubevirtv1.VirtualMachineInstanceSpec{
+						Domain: kubevirtv1.DomainSpec{
+							Devices: kubevirtv1.Devices{
+								Disks: []kubevirtv1.Disk{{
+									Name:       "data",
+									DiskDevice: kubevirtv1.DiskDevice{Disk: &kubevirtv1.DiskTarget{Bus: "scsi"}},
+								}},
+							},
+						},
+						Volumes: []kubevirtv1.Volume{{
+							Name: "data",
+							VolumeSource: kubevirtv1.VolumeSource{
+								PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+									PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-volume"},
+									Hotpluggable:                      hotPlug,
+								},
+							},
+						}},
+					},
+				},
+			},
+		}
+	}
+	newVMI := func(phase kubevirtv1.VolumePhase) *kubevirtv1.VirtualMachineInstance {
+		return &kubevirtv1.VirtualMachineInstance{
+			Status: kubevirtv1.VirtualMachineInstanceStatus{
+				VolumeStatus: []kubevirtv1.VolumeStatus{{Name: "data", Phase: phase}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		vm          *kubevirtv1.VirtualMachine
+		vmi         *kubevirtv1.VirtualMachineInstance
+		expectation string
+	}{
+		{
+			name:        "stopped",
+			vm:          newVM(true),
+			expectation: constants.StateVolumeAttachmentAttached,
+		},
+		{
+			name:        "hot plugged and ready",
+			vm:          newVM(true),
+			vmi:         newVMI(kubevirtv1.VolumeReady),
+			expectation: constants.StateVolumeAttachmentAttached,
+		},
+		{
+			name:        "hot plugged and pending",
+			vm:          newVM(true),
+			vmi:         newVMI(kubevirtv1.VolumePending),
+			expectation: constants.StateVolumeAttachmentAttaching,
+		},
+		{
+			name:        "not hot plugged",
+			vm:          newVM(false),
+			vmi:         newVMI(kubevirtv1.VolumePending),
+			expectation: constants.StateVolumeAttachmentAttached,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stateGetter, err := ResourceVolumeAttachmentStateGetter(tc.vm, tc.vmi, "data")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stateGetter.ID != "default/vm/data" {
+				t.Errorf("expected the ID default/vm/data, got %s", stateGetter.ID)
+			}
+			if actual := stateGetter.States[constants.FieldCommonState]; actual != tc.expectation {
+				t.Errorf("expected the state %s, got %v", tc.expectation, actual)
+			}
+			if actual := stateGetter.States[constants.FieldVolumeAttachmentVolumeName]; actual != "data-volume" {
+				t.Errorf("expected the volume data-volume, got %v", actual)
+			}
+		})
+	}
+
+	if _, err := ResourceVolumeAttachmentStateGetter(newVM(true), nil, "other"); err == nil {
+		t.Error("expected an error for a disk which isn't in the virtual machine")
+	}
+}
@@ -0,0 +1,46 @@
+package importer
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/harvester/terraform-provider-harvester/pkg/constants"
+	"github.com/harvester/terraform-provider-harvester/pkg/helper"
+)
+
+func ResourceVolumeSnapshotStateGetter(obj *unstructured.Unstructured) (*StateGetter, error) {
+	volumeName, _, _ := unstructured.NestedString(obj.Object, "spec", "source", "persistentVolumeClaimName")
+	className, _, _ := unstructured.NestedString(obj.Object, "spec", "volumeSnapshotClassName")
+	readyToUse, _, _ := unstructured.NestedBool(obj.Object, "status", "readyToUse")
+	restoreSize, _, _ := unstructured.NestedString(obj.Object, "status", "restoreSize")
+	creationTime, _, _ := unstructured.NestedString(obj.Object, "status", "creationTime")
+	errorMessage, _, _ := unstructured.NestedString(obj.Object, "status", "error", "message")
+
+	states := map[string]interface{}{
+		constants.FieldCommonNamespace:                       obj.GetNamespace(),
+		constants.FieldCommonName:                            obj.GetName(),
+		constants.FieldCommonDescription:                     GetDescriptions(obj.GetAnnotations()),
+		constants.FieldCommonDeletionProtection:              GetDeletionProtection(obj.GetAnnotations()),
+		constants.FieldCommonTags:                            GetTags(obj.GetLabels()),
+		constants.FieldCommonLabels:                          GetLabels(obj.GetLabels()),
+		constants.FieldVolumeSnapshotVolumeName:              volumeName,
+		constants.FieldVolumeSnapshotVolumeSnapshotClassName: className,
+		constants.FieldVolumeSnapshotReadyToUse:              readyToUse,
+		constants.FieldVolumeSnapshotRestoreSize:             restoreSize,
+		constants.FieldVolumeSnapshotCreationTime:            creationTime,
+		constants.FieldCommonMessage:                         errorMessage,
+	}
+	switch {
+	case readyToUse:
+		states[constants.FieldCommonState] = constants.StateCommonReady
+	case errorMessage != "":
+		states[constants.FieldCommonState] = constants.StateCommonFailed
+	default:
+		states[constants.FieldCommonState] = constants.StateVolumeSnapshotInProgress
+	}
+	return &StateGetter{
+		ID:           helper.BuildID(obj.GetNamespace(), obj.GetName()),
+		Name:         obj.GetName(),
+		ResourceType: constants.ResourceTypeVolumeSnapshot,
+		States:       states,
+	}, nil
+}